@@ -7,6 +7,8 @@ import (
 	routing "github.com/go-ozzo/ozzo-routing/v2"
 	"github.com/go-ozzo/ozzo-routing/v2/content"
 	"github.com/go-ozzo/ozzo-routing/v2/cors"
+	"html/template"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,10 +20,12 @@ import (
 	"url/internal/errors"
 	"url/internal/healthcheck"
 	"url/internal/store"
+	"url/internal/track"
 	"url/internal/urlShortner"
 	"url/pkg/accesslog"
 	"url/pkg/jwt"
 	"url/pkg/log"
+	"url/pkg/policy"
 	"url/pkg/redis"
 )
 
@@ -29,6 +33,9 @@ import (
 var Version = "1.0.0"
 var flagConfig = flag.String("config", "./config/local.yml", "path to the config file")
 
+// purgeInterval is how often the links whose retention has passed are purged from the trash.
+const purgeInterval = time.Hour
+
 func main() {
 	flag.Parse()
 	// create root logger tagged with server version
@@ -71,6 +78,38 @@ func main() {
 		os.Exit(-1)
 	}
 
+	// geo database to look up the country of visitors, it is optional
+	var geoDB *track.GeoDB
+	if config.Cfg.GeoDB.Path != "" {
+		geoDB, err = track.NewGeoDB(config.Cfg.GeoDB.Path)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(-1)
+		}
+		defer geoDB.Close()
+	}
+
+	// policy of the destinations of links
+	destinations, err := newPolicy(config.Cfg)
+	if err != nil {
+		logger.Errorf("failed to load the destination policy: %s", err)
+		os.Exit(-1)
+	}
+	shortener := urlShortner.NewService(psqlStore, psqlStore, urlShortner.NewRepository(redisService, logger), geoDB, destinations, nil, nil, logger)
+
+	// purge the links which are in the trash for longer than the retention
+	purgeCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	retention := time.Duration(config.Cfg.Trash.RetentionDays) * 24 * time.Hour
+	go urlShortner.NewPurger(shortener, retention, logger).Run(purgeCtx, purgeInterval)
+
+	// page shown to browsers visiting links which cannot be followed
+	errorPage, err := urlShortner.LoadErrorPage(config.Cfg.NotFound.Template)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+
 	jwtService, err := jwt.New(jwt.Options{
 		AccessSecret:  config.Cfg.JwtRSAKeys.Access,
 		RefreshSecret: config.Cfg.JwtRSAKeys.Refresh,
@@ -86,11 +125,11 @@ func main() {
 
 	// create a new server
 	s := http.Server{
-		Addr:         bindAddress,                                                           // configure the bind address
-		Handler:      buildHandler(logger, psqlStore, redisService, jwtService, config.Cfg), // set the default handler
-		ReadTimeout:  5 * time.Second,                                                       // max time to read request from the client
-		WriteTimeout: 10 * time.Second,                                                      // max time to write response to the client
-		IdleTimeout:  120 * time.Second,                                                     // max time for connections using TCP Keep-Alive
+		Addr:         bindAddress,                                                                                 // configure the bind address
+		Handler:      buildHandler(logger, psqlStore, redisService, jwtService, shortener, errorPage, config.Cfg), // set the default handler
+		ReadTimeout:  5 * time.Second,                                                                             // max time to read request from the client
+		WriteTimeout: 10 * time.Second,                                                                            // max time to write response to the client
+		IdleTimeout:  120 * time.Second,                                                                           // max time for connections using TCP Keep-Alive
 	}
 
 	// start the server
@@ -123,8 +162,28 @@ func main() {
 	}
 }
 
+// newPolicy creates the destination policy of the config, loading its blocklist file.
+func newPolicy(cfg *config.Config) (*policy.Policy, error) {
+	c := policy.Config{
+		Schemes:      cfg.Policy.Schemes,
+		AllowPrivate: cfg.Policy.AllowPrivate,
+		Shorteners:   cfg.Policy.Shorteners,
+	}
+	if cfg.Policy.Blocklist != "" {
+		blocklist, err := policy.LoadRules(cfg.Policy.Blocklist)
+		if err != nil {
+			return nil, err
+		}
+		c.Blocklist = blocklist
+	}
+	if cfg.Policy.ResolveHosts {
+		c.Resolver = net.DefaultResolver
+	}
+	return policy.New(c), nil
+}
+
 // buildHandler sets up the HTTP routing and builds an HTTP handler.
-func buildHandler(logger log.Logger, psqlStore *store.PostgresStore, redisService *redis.Redis, jwtService *jwt.Auth, cfg *config.Config) http.Handler {
+func buildHandler(logger log.Logger, psqlStore *store.PostgresStore, redisService *redis.Redis, jwtService *jwt.Auth, shortener urlShortner.Service, errorPage *template.Template, cfg *config.Config) http.Handler {
 	router := routing.New()
 
 	router.Use(
@@ -153,9 +212,9 @@ func buildHandler(logger log.Logger, psqlStore *store.PostgresStore, redisServic
 	)
 
 	urlShortner.RegisterHandlers(
-		rg.Group("/"),
-		urlShortner.NewService(psqlStore, psqlStore, urlShortner.NewRepository(redisService, logger), logger),
-		logger, authHandler,
+		rg.Group(""),
+		shortener,
+		errorPage, logger, authHandler,
 	)
 	return router
 }
@@ -34,6 +34,15 @@ func (res resource) analytics(c *routing.Context) error {
 		Unique: c.Query("unique", "false"),
 		Date:   c.Query("date", "monthly"),
 		Mode:   c.Query("mode", "all"),
+		Tag:    c.Query("tag"),
+		Folder: c.Query("folder"),
+		UTM: UTM{
+			Source:   c.Query("utm_source"),
+			Medium:   c.Query("utm_medium"),
+			Campaign: c.Query("utm_campaign"),
+			Term:     c.Query("utm_term"),
+			Content:  c.Query("utm_content"),
+		},
 	}, c.Get("user_id").(int))
 	if err != nil {
 		return errors.BadRequest(err.Error())
@@ -1,9 +1,21 @@
 package analytics
 
 type Config struct {
-	Unique bool
-	Date   string
-	Mode   string
+	Unique   bool
+	Date     string
+	Mode     string
+	Tag      string
+	FolderID int
+	UTM      UTM
+}
+
+// UTM filters the links by their campaign parameters, empty values match every link.
+type UTM struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
 }
 
 
@@ -31,3 +43,24 @@ type StatsBrowserMode struct {
 	BrowserFirefox  int    `db:"browser_firefox" json:"browser_firefox"`
 	BrowserOthers   int    `db:"browser_others" json:"browser_others"`
 }
+
+type StatsVariantMode struct {
+	Path     string `db:"path" json:"path"`
+	Variant  string `db:"variant" json:"variant"`
+	Visitors int    `db:"visitors" json:"visitors"`
+}
+
+type StatsSourceMode struct {
+	Path     string `db:"path" json:"path"`
+	Source   string `db:"source" json:"source"`
+	Visitors int    `db:"visitors" json:"visitors"`
+}
+
+type StatsUTMMode struct {
+	Source   string `db:"utm_source" json:"utm_source"`
+	Medium   string `db:"utm_medium" json:"utm_medium"`
+	Campaign string `db:"utm_campaign" json:"utm_campaign"`
+	Term     string `db:"utm_term" json:"utm_term"`
+	Content  string `db:"utm_content" json:"utm_content"`
+	Visitors int    `db:"visitors" json:"visitors"`
+}
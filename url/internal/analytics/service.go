@@ -10,6 +10,9 @@ var modeTypes = []string{
 	"all",
 	"platform",
 	"browser",
+	"variant",
+	"source",
+	"utm",
 }
 
 var dateTypes = []string{
@@ -32,6 +35,9 @@ type queries struct {
 	Unique string
 	Date   string
 	Mode   string
+	Tag    string
+	Folder string
+	UTM    UTM
 }
 
 // NewService creates a new service.
@@ -58,10 +64,19 @@ func (s service) queriesValidator(queries queries) (Config, error) {
 	if !contains(dateTypes, queries.Date) {
 		return Config{}, fmt.Errorf("enter the correct date type, %s is not contain %s", dateTypes, queries.Date)
 	}
+	folderID := 0
+	if queries.Folder != "" {
+		if folderID, err = strconv.Atoi(queries.Folder); err != nil || folderID < 1 {
+			return Config{}, fmt.Errorf("enter the correct folder, %s is not a folder id", queries.Folder)
+		}
+	}
 	return Config{
-		Unique: uniq,
-		Date:   queries.Date,
-		Mode:   queries.Mode,
+		Unique:   uniq,
+		Date:     queries.Date,
+		Mode:     queries.Mode,
+		Tag:      queries.Tag,
+		FolderID: folderID,
+		UTM:      queries.UTM,
 	}, nil
 }
 
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"url/pkg/log"
 
 	"github.com/qiangxue/go-env"
@@ -9,7 +11,12 @@ import (
 )
 
 const (
-	defaultServerPort = 8080
+	defaultServerPort     = 8080
+	defaultRedirectStatus = http.StatusMovedPermanently
+	defaultRetentionDays  = 30
+
+	// minCookieSecretLength is the shortest secret signing the cookies of unlocked links.
+	minCookieSecretLength = 32
 )
 
 // Cfg is holder of config load file
@@ -20,9 +27,13 @@ type Config struct {
 	ServerPort int `yaml:"server_port" env:"SERVER_PORT"`
 
 	Options struct {
-		Schema  string `yaml:"schema" env:"SCHEMA"`
-		Prefix  string `yaml:"prefix" env:"PREFIX"`
-		BaseURL string `yaml:"base_url" env:"BASE_URL"`
+		Schema         string `yaml:"schema" env:"SCHEMA"`
+		Prefix         string `yaml:"prefix" env:"PREFIX"`
+		BaseURL        string `yaml:"base_url" env:"BASE_URL"`
+		CookieSecret   string `yaml:"cookie_secret" env:"COOKIE_SECRET,secret"`
+		RedirectStatus int    `yaml:"redirect_status" env:"REDIRECT_STATUS"`
+		StripTracking  bool   `yaml:"strip_tracking_params" env:"STRIP_TRACKING_PARAMS"`
+		InactiveURL    string `yaml:"inactive_url" env:"INACTIVE_URL"`
 	} `yaml:"options"`
 
 	Redis struct {
@@ -39,6 +50,32 @@ type Config struct {
 		DBName   string `yaml:"db_name" env:"POSTGRES_DB_NAME"`
 	} `yaml:"postgres"`
 
+	// NotFound is where browsers visiting links which cannot be followed are sent, unless the owner has a fallback.
+	// The URL takes precedence over the template, which is the path of an html/template page.
+	NotFound struct {
+		URL      string `yaml:"url" env:"NOT_FOUND_URL"`
+		Template string `yaml:"template" env:"NOT_FOUND_TEMPLATE"`
+	} `yaml:"not_found"`
+
+	// Policy limits the destinations of links.
+	// The blocklist is the path of a file of domain and wildcard rules, one per line.
+	Policy struct {
+		Schemes      []string `yaml:"schemes" env:"POLICY_SCHEMES"`
+		AllowPrivate bool     `yaml:"allow_private" env:"POLICY_ALLOW_PRIVATE"`
+		ResolveHosts bool     `yaml:"resolve_hosts" env:"POLICY_RESOLVE_HOSTS"`
+		Shorteners   []string `yaml:"shorteners" env:"POLICY_SHORTENERS"`
+		Blocklist    string   `yaml:"blocklist" env:"POLICY_BLOCKLIST"`
+	} `yaml:"policy"`
+
+	// Trash keeps deleted links for the retention days, until they are purged.
+	Trash struct {
+		RetentionDays int `yaml:"retention_days" env:"TRASH_RETENTION_DAYS"`
+	} `yaml:"trash"`
+
+	GeoDB struct {
+		Path string `yaml:"path" env:"GEODB_PATH"`
+	} `yaml:"geodb"`
+
 	JwtRSAKeys struct{
 		Access string `yaml:"access" env:"JWT_RSA_KEYS_ACCESS_KEY"`
 		Refresh string `yaml:"refresh" env:"JWT_RSA_KEYS_REFRESH_KEY"`
@@ -51,6 +88,8 @@ func Load(file string, logger log.Logger) (*Config, error) {
 	c := Config{
 		ServerPort: defaultServerPort,
 	}
+	c.Options.RedirectStatus = defaultRedirectStatus
+	c.Trash.RetentionDays = defaultRetentionDays
 
 	// load from YAML config file
 	bytes, err := ioutil.ReadFile(file)
@@ -66,5 +105,17 @@ func Load(file string, logger log.Logger) (*Config, error) {
 		return nil, err
 	}
 
+	switch c.Options.RedirectStatus {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, fmt.Errorf("redirect status %d is not one of 301, 302, 307 or 308", c.Options.RedirectStatus)
+	}
+	if len(c.Options.CookieSecret) < minCookieSecretLength {
+		return nil, fmt.Errorf("cookie secret is shorter than %d characters", minCookieSecretLength)
+	}
+	if c.Trash.RetentionDays < 1 {
+		return nil, fmt.Errorf("trash retention days %d is less than 1", c.Trash.RetentionDays)
+	}
+
 	return &c, err
 }
@@ -45,6 +45,28 @@ func NotFound(msg string) ErrorResponse {
 	}
 }
 
+// Conflict creates a new error response representing a conflict with the current state of a resource (HTTP 409)
+func Conflict(msg string) ErrorResponse {
+	if msg == "" {
+		msg = "The request conflicts with the current state of the resource."
+	}
+	return ErrorResponse{
+		Status:  http.StatusConflict,
+		Message: msg,
+	}
+}
+
+// Gone creates a new error response representing a resource that is no longer available (HTTP 410)
+func Gone(msg string) ErrorResponse {
+	if msg == "" {
+		msg = "The requested resource is no longer available."
+	}
+	return ErrorResponse{
+		Status:  http.StatusGone,
+		Message: msg,
+	}
+}
+
 // Unauthorized creates a new error response representing an authentication/authorization failure (HTTP 401)
 func Unauthorized(msg string) ErrorResponse {
 	if msg == "" {
@@ -81,6 +103,14 @@ func BadRequest(msg string) ErrorResponse {
 type invalidField struct {
 	Field string `json:"field"`
 	Error string `json:"error"`
+	Rule  string `json:"rule,omitempty"`
+}
+
+// FieldError is a field of the submitted data which breaks a rule checked outside of the validator.
+type FieldError struct {
+	Field string
+	Rule  string
+	Error string
 }
 
 // InvalidInput creates a new error response representing a data validation error (HTTP 400).
@@ -103,3 +133,21 @@ func InvalidInput(errs validator.ValidationErrors) ErrorResponse {
 		Details: details,
 	}
 }
+
+// InvalidFields creates a new error response representing a data validation error with the given fields (HTTP 400).
+func InvalidFields(errs []FieldError) ErrorResponse {
+	details := make([]invalidField, 0, len(errs))
+	for _, err := range errs {
+		details = append(details, invalidField{
+			Field: err.Field,
+			Error: err.Error,
+			Rule:  err.Rule,
+		})
+	}
+
+	return ErrorResponse{
+		Status:  http.StatusBadRequest,
+		Message: "There is some problem with the data you submitted.",
+		Details: details,
+	}
+}
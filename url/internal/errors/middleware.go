@@ -25,7 +25,7 @@ func Handler(logger log.Logger) routing.Handler {
 			}
 
 			if err != nil {
-				res := buildErrorResponse(err)
+				res := BuildErrorResponse(err)
 				if res.StatusCode() == http.StatusInternalServerError {
 					l.Errorf("encountered internal server error: %v", err)
 				}
@@ -41,8 +41,8 @@ func Handler(logger log.Logger) routing.Handler {
 	}
 }
 
-// buildErrorResponse builds an error response from an error.
-func buildErrorResponse(err error) ErrorResponse {
+// BuildErrorResponse builds an error response from an error.
+func BuildErrorResponse(err error) ErrorResponse {
 	fmt.Println(err)
 	switch err.(type) {
 	case validator.ValidationErrors:
@@ -4,14 +4,20 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"strings"
+	"time"
 	"url/internal/analytics"
 	"url/internal/auth"
 	"url/internal/track"
+	"url/internal/urlShortner"
 	"url/pkg/log"
 )
 
+// linkColumns are the columns of the links table selected into urlShortner.Link.
+const linkColumns = `l.link_id, l.url, l.canonical_url, l.shortner_path, l.domain, l.expires_at, l.max_clicks, l.password, l.redirect_status, l.targets, l.variants, l.folder_id, l.active_from, l.active_until, l.inactive_url, l.title, l.description, l.interstitial, l.state, l.ban_reason, l.og, l.og_fetched, l.utm_source, l.utm_medium, l.utm_campaign, l.utm_term, l.utm_content, l.created_at, l.updated_at, l.deleted_at, l.deleted_clicks,
+	ARRAY(SELECT t.name FROM link_tags lt INNER JOIN tags t ON t.tag_id = lt.tag_id WHERE lt.link_id = l.link_id ORDER BY t.name) AS tags`
+
 type PostgresConfig struct {
 	Logger   log.Logger
 	Host     string
@@ -67,12 +73,24 @@ func (store *PostgresStore) Rollback(tx *sqlx.Tx) {
 	}
 }
 
+// Savepoint implements the Store interface.
+func (store *PostgresStore) Savepoint(tx *sqlx.Tx, name string) error {
+	_, err := tx.Exec(`SAVEPOINT ` + name)
+	return err
+}
+
+// RollbackToSavepoint implements the Store interface.
+func (store *PostgresStore) RollbackToSavepoint(tx *sqlx.Tx, name string) error {
+	_, err := tx.Exec(`ROLLBACK TO SAVEPOINT ` + name)
+	return err
+}
+
 // SaveHits implements the Store interface.
 func (store *PostgresStore) SaveHits(hits []track.Hit) error {
-	const hitParams = 19
+	const hitParams = 22
 	args := make([]interface{}, 0, len(hits)*hitParams)
 	var query strings.Builder
-	query.WriteString(`INSERT INTO "hit" (tenant_id, fingerprint, session, path, url, language, user_agent, referrer, os, os_version, browser, browser_version, country_code, desktop, mobile, screen_width, screen_height, screen_class, time) VALUES `)
+	query.WriteString(`INSERT INTO "hit" (tenant_id, fingerprint, session, path, url, language, user_agent, referrer, os, os_version, browser, browser_version, country_code, desktop, mobile, screen_width, screen_height, screen_class, target, variant, source, time) VALUES `)
 
 	for i, hit := range hits {
 		args = append(args, hit.TenantID)
@@ -93,10 +111,16 @@ func (store *PostgresStore) SaveHits(hits []track.Hit) error {
 		args = append(args, hit.ScreenWidth)
 		args = append(args, hit.ScreenHeight)
 		args = append(args, hit.ScreenClass)
+		args = append(args, hit.Target)
+		args = append(args, hit.Variant)
+		args = append(args, hit.Source)
 		args = append(args, hit.Time)
 		index := i * hitParams
-		query.WriteString(fmt.Sprintf(`($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d),`,
-			index+1, index+2, index+3, index+4, index+5, index+6, index+7, index+8, index+9, index+10, index+11, index+12, index+13, index+14, index+15, index+16, index+17, index+18, index+19))
+		placeholders := make([]string, hitParams)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", index+j+1)
+		}
+		query.WriteString(`(` + strings.Join(placeholders, ", ") + `),`)
 	}
 
 	queryStr := query.String()
@@ -133,13 +157,18 @@ func (store *PostgresStore) VerifyEmail(tx *sqlx.Tx, email string) error {
 	return err
 }
 
-func (store *PostgresStore) CreateLink(tx *sqlx.Tx, url, path string) (int, error) {
+func (store *PostgresStore) CreateLink(tx *sqlx.Tx, link urlShortner.Link) (int, error) {
 	if tx == nil {
 		tx = store.NewTx()
 		defer store.Commit(tx)
 	}
 	var linkID int
-	err := tx.Get(&linkID,`INSERT INTO links (url, shortner_path) values($1, $2) RETURNING link_id `, url, path)
+	err := tx.Get(&linkID, `INSERT INTO links (url, canonical_url, shortner_path, domain, expires_at, max_clicks, password, redirect_status, targets, variants, folder_id, active_from, active_until, inactive_url,
+		description, interstitial, og, utm_source, utm_medium, utm_campaign, utm_term, utm_content)
+		values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22) RETURNING link_id `,
+		link.URL, link.CanonicalURL, link.Code, link.Domain, link.ExpiresAt, link.MaxClicks, link.Password, link.Status, link.Targets, link.Variants, link.FolderID,
+		link.ActiveFrom, link.ActiveUntil, link.InactiveURL, link.Description, link.Interstitial, link.OG,
+		link.UTM.Source, link.UTM.Medium, link.UTM.Campaign, link.UTM.Term, link.UTM.Content)
 	if err != nil {
 		fmt.Println(err)
 		return 0, err
@@ -156,6 +185,612 @@ func (store *PostgresStore) CreateUserLinkRelation(tx *sqlx.Tx, userID int, link
 	return err
 }
 
+func (store *PostgresStore) AttachTags(tx *sqlx.Tx, userID, linkID int, tags []string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	if _, err := tx.Exec(`INSERT INTO tags (user_id, name) SELECT $1, unnest($2::text[]) ON CONFLICT (user_id, name) DO NOTHING`,
+		userID, pq.Array(tags)); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO link_tags (link_id, tag_id) SELECT $1, tag_id FROM tags WHERE user_id = $2 AND name = ANY($3)
+		ON CONFLICT DO NOTHING`, linkID, userID, pq.Array(tags))
+	return err
+}
+
+func (store *PostgresStore) ReplaceTags(tx *sqlx.Tx, userID, linkID int, tags []string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE link_id = $1`, linkID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return store.AttachTags(tx, userID, linkID, tags)
+}
+
+func (store *PostgresStore) FindUserTags(tx *sqlx.Tx, userID int) ([]urlShortner.Tag, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	tags := make([]urlShortner.Tag, 0)
+	err := tx.Select(&tags, `SELECT t.tag_id, t.name, (SELECT count(*) FROM link_tags lt INNER JOIN links l ON l.link_id = lt.link_id
+		WHERE lt.tag_id = t.tag_id AND l.deleted_at IS NULL) AS links
+		FROM tags t WHERE t.user_id = $1 ORDER BY t.name`, userID)
+	return tags, err
+}
+
+func (store *PostgresStore) FindUserTag(tx *sqlx.Tx, userID, tagID int) (urlShortner.Tag, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var tag urlShortner.Tag
+	err := tx.Get(&tag, `SELECT t.tag_id, t.name, (SELECT count(*) FROM link_tags lt INNER JOIN links l ON l.link_id = lt.link_id
+		WHERE lt.tag_id = t.tag_id AND l.deleted_at IS NULL) AS links
+		FROM tags t WHERE t.user_id = $1 AND t.tag_id = $2`, userID, tagID)
+	return tag, err
+}
+
+func (store *PostgresStore) CreateTag(tx *sqlx.Tx, userID int, name string) (int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var tagID int
+	err := tx.Get(&tagID, `INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING tag_id`, userID, name)
+	return tagID, err
+}
+
+func (store *PostgresStore) RenameTag(tx *sqlx.Tx, userID, tagID int, name string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE tags SET name = $1 WHERE user_id = $2 AND tag_id = $3`, name, userID, tagID))
+}
+
+func (store *PostgresStore) DeleteTag(tx *sqlx.Tx, userID, tagID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE tag_id IN (SELECT tag_id FROM tags WHERE user_id = $1 AND tag_id = $2)`, userID, tagID); err != nil {
+		return err
+	}
+	return affected(tx.Exec(`DELETE FROM tags WHERE user_id = $1 AND tag_id = $2`, userID, tagID))
+}
+
+func (store *PostgresStore) FindUserFolders(tx *sqlx.Tx, userID int) ([]urlShortner.Folder, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	folders := make([]urlShortner.Folder, 0)
+	err := tx.Select(&folders, `SELECT f.folder_id, f.name, (SELECT count(*) FROM links l WHERE l.folder_id = f.folder_id AND l.deleted_at IS NULL) AS links
+		FROM folders f WHERE f.user_id = $1 ORDER BY f.name`, userID)
+	return folders, err
+}
+
+func (store *PostgresStore) FindUserFolder(tx *sqlx.Tx, userID, folderID int) (urlShortner.Folder, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var folder urlShortner.Folder
+	err := tx.Get(&folder, `SELECT f.folder_id, f.name, (SELECT count(*) FROM links l WHERE l.folder_id = f.folder_id AND l.deleted_at IS NULL) AS links
+		FROM folders f WHERE f.user_id = $1 AND f.folder_id = $2`, userID, folderID)
+	return folder, err
+}
+
+func (store *PostgresStore) CreateFolder(tx *sqlx.Tx, userID int, name string) (int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var folderID int
+	err := tx.Get(&folderID, `INSERT INTO folders (user_id, name) VALUES ($1, $2) RETURNING folder_id`, userID, name)
+	return folderID, err
+}
+
+func (store *PostgresStore) RenameFolder(tx *sqlx.Tx, userID, folderID int, name string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE folders SET name = $1 WHERE user_id = $2 AND folder_id = $3`, name, userID, folderID))
+}
+
+func (store *PostgresStore) DeleteFolder(tx *sqlx.Tx, userID, folderID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	if _, err := tx.Exec(`UPDATE links SET folder_id = NULL, updated_at = now()
+		WHERE folder_id IN (SELECT folder_id FROM folders WHERE user_id = $1 AND folder_id = $2)`, userID, folderID); err != nil {
+		return err
+	}
+	return affected(tx.Exec(`DELETE FROM folders WHERE user_id = $1 AND folder_id = $2`, userID, folderID))
+}
+
+func (store *PostgresStore) MoveLink(tx *sqlx.Tx, linkID int, folderID *int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`UPDATE links SET folder_id = $1, updated_at = now() WHERE link_id = $2`, folderID, linkID)
+	return err
+}
+
+// affected returns sql.ErrNoRows if the statement changed no rows.
+func affected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (store *PostgresStore) StreamUserLinks(tx *sqlx.Tx, userID int, fn func(urlShortner.LinkClicks) error) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	rows, err := tx.Queryx(`SELECT `+linkColumns+`, (SELECT count(*) FROM hit h WHERE h.path = l.shortner_path) AS clicks
+		FROM links l INNER JOIN user_links ul ON ul.link_id = l.link_id
+		WHERE ul.user_id = $1 AND l.deleted_at IS NULL ORDER BY l.created_at, l.link_id`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var link urlShortner.LinkClicks
+		if err := rows.StructScan(&link); err != nil {
+			return err
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (store *PostgresStore) FindUserLinks(tx *sqlx.Tx, userID int, filter urlShortner.LinkFilter) ([]urlShortner.Link, int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	args := []interface{}{userID}
+	where := ` WHERE ul.user_id = $1 AND l.deleted_at IS NULL`
+	order := `l.created_at DESC, l.link_id DESC`
+	if filter.Deleted {
+		where = ` WHERE ul.user_id = $1 AND l.deleted_at IS NOT NULL`
+		order = `l.deleted_at DESC, l.link_id DESC`
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+filter.Search+"%")
+		where += fmt.Sprintf(` AND (l.url ILIKE $%d OR l.canonical_url ILIKE $%d OR l.shortner_path ILIKE $%d)`, len(args), len(args), len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		where += fmt.Sprintf(` AND l.created_at >= $%d`, len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		where += fmt.Sprintf(` AND l.created_at < $%d`, len(args))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		where += fmt.Sprintf(` AND l.link_id IN (SELECT lt.link_id FROM link_tags lt INNER JOIN tags t ON t.tag_id = lt.tag_id
+			WHERE t.user_id = $1 AND t.name = $%d)`, len(args))
+	}
+	if filter.FolderID != 0 {
+		args = append(args, filter.FolderID)
+		where += fmt.Sprintf(` AND l.folder_id = $%d`, len(args))
+	}
+	from := ` FROM links l INNER JOIN user_links ul ON ul.link_id = l.link_id`
+
+	var total int
+	if err := tx.Get(&total, `SELECT count(*)`+from+where, args...); err != nil {
+		return nil, 0, err
+	}
+	links := make([]urlShortner.Link, 0)
+	query := `SELECT ` + linkColumns + from + where +
+		fmt.Sprintf(` ORDER BY %s LIMIT $%d OFFSET $%d`, order, len(args)+1, len(args)+2)
+	if err := tx.Select(&links, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+func (store *PostgresStore) FindUserLink(tx *sqlx.Tx, userID int, code string) (urlShortner.Link, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var link urlShortner.Link
+	err := tx.Get(&link, `SELECT `+linkColumns+` FROM links l
+		INNER JOIN user_links ul ON ul.link_id = l.link_id
+		WHERE ul.user_id = $1 AND l.shortner_path = $2 AND l.deleted_at IS NULL`, userID, code)
+	return link, err
+}
+
+func (store *PostgresStore) FindUserTrashedLink(tx *sqlx.Tx, userID int, code string) (urlShortner.Link, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var link urlShortner.Link
+	err := tx.Get(&link, `SELECT `+linkColumns+` FROM links l
+		INNER JOIN user_links ul ON ul.link_id = l.link_id
+		WHERE ul.user_id = $1 AND l.shortner_path = $2 AND l.deleted_at IS NOT NULL`, userID, code)
+	return link, err
+}
+
+func (store *PostgresStore) FindUserLinkByURL(tx *sqlx.Tx, userID int, canonicalURL string) (urlShortner.Link, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var link urlShortner.Link
+	err := tx.Get(&link, `SELECT `+linkColumns+` FROM links l
+		INNER JOIN user_links ul ON ul.link_id = l.link_id
+		WHERE ul.user_id = $1 AND l.canonical_url = $2
+		AND l.expires_at IS NULL AND l.max_clicks IS NULL AND l.password IS NULL AND l.redirect_status IS NULL
+		AND l.targets IS NULL AND l.variants IS NULL AND l.folder_id IS NULL
+		AND l.active_from IS NULL AND l.active_until IS NULL AND l.description IS NULL AND NOT l.interstitial AND l.og IS NULL
+		AND l.utm_source = '' AND l.utm_medium = '' AND l.utm_campaign = '' AND l.utm_term = '' AND l.utm_content = '' AND l.domain = '' AND l.state = 'active' AND l.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM link_tags lt WHERE lt.link_id = l.link_id)
+		ORDER BY l.created_at DESC LIMIT 1`, userID, canonicalURL)
+	return link, err
+}
+
+func (store *PostgresStore) UpdateLinkURL(tx *sqlx.Tx, linkID int, url, canonicalURL string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`UPDATE links SET url = $1, canonical_url = $2, updated_at = now() WHERE link_id = $3`, url, canonicalURL, linkID)
+	return err
+}
+
+func (store *PostgresStore) UpdateLinkMetadata(tx *sqlx.Tx, code, title string, og urlShortner.OpenGraph) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`UPDATE links SET title = NULLIF($1, ''), og_fetched = $2 WHERE shortner_path = $3`, title, og, code)
+	return err
+}
+
+func (store *PostgresStore) UpdateLinkOG(tx *sqlx.Tx, linkID int, og urlShortner.OpenGraph) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`UPDATE links SET og = $1, updated_at = now() WHERE link_id = $2`, og, linkID)
+	return err
+}
+
+func (store *PostgresStore) AddLinkChange(tx *sqlx.Tx, linkID, userID int, previousURL, url string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`INSERT INTO link_changes (link_id, user_id, previous_url, url) VALUES ($1, $2, $3, $4)`,
+		linkID, userID, previousURL, url)
+	return err
+}
+
+func (store *PostgresStore) FindLinkChanges(tx *sqlx.Tx, linkID int) ([]urlShortner.LinkChange, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	changes := make([]urlShortner.LinkChange, 0)
+	err := tx.Select(&changes, `SELECT lc.change_id, lc.previous_url, lc.url, u.username AS changed_by, lc.changed_at
+		FROM link_changes lc INNER JOIN users u ON u.user_id = lc.user_id
+		WHERE lc.link_id = $1 ORDER BY lc.changed_at DESC, lc.change_id DESC`, linkID)
+	return changes, err
+}
+
+func (store *PostgresStore) FindUserFallback(tx *sqlx.Tx, userID int) (urlShortner.Fallback, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var fallback urlShortner.Fallback
+	err := tx.Get(&fallback, `SELECT url, template FROM user_fallbacks WHERE user_id = $1`, userID)
+	return fallback, err
+}
+
+func (store *PostgresStore) SaveUserFallback(tx *sqlx.Tx, userID int, fallback urlShortner.Fallback) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`INSERT INTO user_fallbacks (user_id, url, template) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET url = excluded.url, template = excluded.template`, userID, fallback.URL, fallback.Template)
+	return err
+}
+
+func (store *PostgresStore) FindCodeFallback(tx *sqlx.Tx, code string) (urlShortner.Fallback, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var fallback urlShortner.Fallback
+	err := tx.Get(&fallback, `SELECT uf.url, uf.template FROM links l
+		INNER JOIN user_links ul ON ul.link_id = l.link_id
+		INNER JOIN user_fallbacks uf ON uf.user_id = ul.user_id
+		WHERE l.shortner_path = $1`, code)
+	return fallback, err
+}
+
+func (store *PostgresStore) FindUserDomains(tx *sqlx.Tx, userID int) ([]urlShortner.Domain, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	domains := make([]urlShortner.Domain, 0)
+	err := tx.Select(&domains, `SELECT domain_id, name, token, verified_at, created_at FROM domains WHERE user_id = $1 ORDER BY name`, userID)
+	return domains, err
+}
+
+func (store *PostgresStore) FindUserDomain(tx *sqlx.Tx, userID, domainID int) (urlShortner.Domain, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var domain urlShortner.Domain
+	err := tx.Get(&domain, `SELECT domain_id, name, token, verified_at, created_at FROM domains WHERE user_id = $1 AND domain_id = $2`, userID, domainID)
+	return domain, err
+}
+
+func (store *PostgresStore) FindUserDomainByName(tx *sqlx.Tx, userID int, name string) (urlShortner.Domain, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var domain urlShortner.Domain
+	err := tx.Get(&domain, `SELECT domain_id, name, token, verified_at, created_at FROM domains WHERE user_id = $1 AND name = $2`, userID, name)
+	return domain, err
+}
+
+func (store *PostgresStore) CreateDomain(tx *sqlx.Tx, userID int, name, token string) (int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var domainID int
+	err := tx.Get(&domainID, `INSERT INTO domains (user_id, name, token) VALUES ($1, $2, $3) RETURNING domain_id`, userID, name, token)
+	return domainID, err
+}
+
+func (store *PostgresStore) VerifyDomain(tx *sqlx.Tx, domainID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE domains SET verified_at = now() WHERE domain_id = $1`, domainID))
+}
+
+func (store *PostgresStore) DeleteDomain(tx *sqlx.Tx, userID, domainID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`DELETE FROM domains WHERE user_id = $1 AND domain_id = $2`, userID, domainID))
+}
+
+func (store *PostgresStore) DomainHasLinks(tx *sqlx.Tx, name string) (bool, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var exists bool
+	err := tx.Get(&exists, `SELECT EXISTS (SELECT 1 FROM links WHERE domain = $1)`, name)
+	return exists, err
+}
+
+func (store *PostgresStore) FindLink(tx *sqlx.Tx, code string) (urlShortner.Link, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var link urlShortner.Link
+	err := tx.Get(&link, `SELECT `+linkColumns+` FROM links l WHERE l.shortner_path = $1 AND l.deleted_at IS NULL`, code)
+	return link, err
+}
+
+func (store *PostgresStore) SetLinkState(tx *sqlx.Tx, linkID int, state string, banReason *string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE links SET state = $1, ban_reason = COALESCE($2, ban_reason), updated_at = now() WHERE link_id = $3`,
+		state, banReason, linkID))
+}
+
+func (store *PostgresStore) CreateReport(tx *sqlx.Tx, report urlShortner.LinkReport) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`INSERT INTO link_reports (link_id, reason, details, fingerprint) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (link_id, fingerprint, reported_on) DO NOTHING`, report.LinkID, report.Reason, report.Details, report.Fingerprint)
+	return err
+}
+
+func (store *PostgresStore) FindReports(tx *sqlx.Tx, reviewed bool, offset, limit int) ([]urlShortner.LinkReport, int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var total int
+	if err := tx.Get(&total, `SELECT count(*) FROM link_reports WHERE (reviewed_at IS NOT NULL) = $1`, reviewed); err != nil {
+		return nil, 0, err
+	}
+	reports := make([]urlShortner.LinkReport, 0)
+	err := tx.Select(&reports, `SELECT r.report_id, r.link_id, l.shortner_path, l.url, l.state, r.reason, r.details, r.fingerprint, r.created_at, r.reviewed_at
+		FROM link_reports r INNER JOIN links l ON l.link_id = r.link_id
+		WHERE (r.reviewed_at IS NOT NULL) = $1
+		ORDER BY r.created_at DESC, r.report_id DESC OFFSET $2 LIMIT $3`, reviewed, offset, limit)
+	return reports, total, err
+}
+
+func (store *PostgresStore) ReviewReport(tx *sqlx.Tx, reportID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE link_reports SET reviewed_at = now() WHERE report_id = $1 AND reviewed_at IS NULL`, reportID))
+}
+
+func (store *PostgresStore) ReviewLinkReports(tx *sqlx.Tx, linkID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`UPDATE link_reports SET reviewed_at = now() WHERE link_id = $1 AND reviewed_at IS NULL`, linkID)
+	return err
+}
+
+func (store *PostgresStore) IsAdmin(tx *sqlx.Tx, userID int) (bool, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var admin bool
+	err := tx.Get(&admin, `SELECT is_admin FROM users WHERE user_id = $1`, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return admin, err
+}
+
+func (store *PostgresStore) FindUserID(tx *sqlx.Tx, username string) (int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	var userID int
+	err := tx.Get(&userID, `SELECT user_id FROM users WHERE username = $1`, username)
+	return userID, err
+}
+
+func (store *PostgresStore) TransferLinks(tx *sqlx.Tx, fromUserID, toUserID int, linkIDs []int) (int, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	if linkIDs == nil {
+		if err := tx.Select(&linkIDs, `SELECT ul.link_id FROM user_links ul INNER JOIN links l ON l.link_id = ul.link_id
+			WHERE ul.user_id = $1 AND l.deleted_at IS NULL AND l.domain = '' FOR UPDATE OF ul`, fromUserID); err != nil {
+			return 0, err
+		}
+	}
+	ids := pq.Array(linkIDs)
+	if _, err := tx.Exec(`INSERT INTO tags (user_id, name)
+		SELECT DISTINCT $1::int, t.name FROM link_tags lt INNER JOIN tags t ON t.tag_id = lt.tag_id WHERE lt.link_id = ANY($2)
+		ON CONFLICT (user_id, name) DO NOTHING`, toUserID, ids); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE link_tags lt SET tag_id = nt.tag_id FROM tags ot, tags nt
+		WHERE lt.link_id = ANY($2) AND ot.tag_id = lt.tag_id AND nt.user_id = $1 AND nt.name = ot.name`, toUserID, ids); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE links SET folder_id = NULL, updated_at = now() WHERE link_id = ANY($1) AND folder_id IS NOT NULL`, ids); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(`UPDATE user_links SET user_id = $1 WHERE user_id = $2 AND link_id = ANY($3)`, toUserID, fromUserID, ids)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
+
+func (store *PostgresStore) CopyHits(tx *sqlx.Tx, fromCode, toCode string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	const columns = `tenant_id, fingerprint, session, url, language, user_agent, referrer, os, os_version, browser, browser_version, country_code, desktop, mobile, screen_width, screen_height, screen_class, target, variant, source, time`
+	_, err := tx.Exec(`INSERT INTO "hit" (path, `+columns+`) SELECT $2, `+columns+` FROM "hit" WHERE path = $1`, fromCode, toCode)
+	return err
+}
+
+func (store *PostgresStore) DeleteHits(tx *sqlx.Tx, code string) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	_, err := tx.Exec(`DELETE FROM "hit" WHERE path = $1`, code)
+	return err
+}
+
+func (store *PostgresStore) SoftDeleteLink(tx *sqlx.Tx, linkID int, clicks int64) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE links SET deleted_at = now(), deleted_clicks = $2, updated_at = now()
+		WHERE link_id = $1 AND deleted_at IS NULL`, linkID, clicks))
+}
+
+func (store *PostgresStore) RestoreLink(tx *sqlx.Tx, linkID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	return affected(tx.Exec(`UPDATE links SET deleted_at = NULL, deleted_clicks = NULL, updated_at = now()
+		WHERE link_id = $1 AND deleted_at IS NOT NULL`, linkID))
+}
+
+func (store *PostgresStore) FindTrash(tx *sqlx.Tx, before time.Time, limit int) ([]urlShortner.Link, error) {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	links := make([]urlShortner.Link, 0)
+	err := tx.Select(&links, `SELECT `+linkColumns+` FROM links l
+		WHERE l.deleted_at IS NOT NULL AND l.deleted_at < $1 ORDER BY l.deleted_at, l.link_id LIMIT $2`, before, limit)
+	return links, err
+}
+
+func (store *PostgresStore) DeleteLink(tx *sqlx.Tx, linkID int) error {
+	if tx == nil {
+		tx = store.NewTx()
+		defer store.Commit(tx)
+	}
+	if _, err := tx.Exec(`DELETE FROM link_tags WHERE link_id = $1`, linkID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM link_changes WHERE link_id = $1`, linkID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM link_reports WHERE link_id = $1`, linkID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_links WHERE link_id = $1`, linkID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM links WHERE link_id = $1`, linkID)
+	return err
+}
+
 func (store *PostgresStore) GetAnalytics(tx *sqlx.Tx, conf analytics.Config, userID int) (interface{}, error) {
 	var query string
 	var time string
@@ -172,6 +807,16 @@ func (store *PostgresStore) GetAnalytics(tx *sqlx.Tx, conf analytics.Config, use
 	} else {
 		time = "- interval '1 month' "
 	}
+	filter, args := analyticsFilter(conf, userID)
+	if conf.Mode == "variant" {
+		return store.getVariantAnalytics(tx, conf, time, filter, args)
+	}
+	if conf.Mode == "source" {
+		return store.getSourceAnalytics(tx, conf, time, filter, args)
+	}
+	if conf.Mode == "utm" {
+		return store.getUTMAnalytics(tx, conf, time, filter, args)
+	}
 	if conf.Unique {
 		query += `WITH hit_with_time
 		AS
@@ -205,12 +850,12 @@ func (store *PostgresStore) GetAnalytics(tx *sqlx.Tx, conf analytics.Config, use
 		inner join user_links ul on ul.user_id = users.user_id
 		inner join links l on l.link_id = ul.link_id
 		inner join hit_with_time h on h.path = l.shortner_path
-		where users.user_id = $1
+		where users.user_id = $1 ` + filter + `
 		group by h.path`
 
 	if conf.Mode == "all" {
 		var stats []analytics.Stats
-		if err := tx.Select(&stats, query, userID); err != nil {
+		if err := tx.Select(&stats, query, args...); err != nil {
 			return nil, err
 		}
 		return stats, nil
@@ -218,15 +863,98 @@ func (store *PostgresStore) GetAnalytics(tx *sqlx.Tx, conf analytics.Config, use
 
 	if conf.Mode == "platform" {
 		var stats []analytics.StatsPlatformMode
-		if err := tx.Select(&stats, query, userID); err != nil {
+		if err := tx.Select(&stats, query, args...); err != nil {
 			return nil, err
 		}
 		return stats, nil
 	}
 
 	var stats []analytics.StatsBrowserMode
-	if err := tx.Select(&stats, query, userID); err != nil {
+	if err := tx.Select(&stats, query, args...); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// getVariantAnalytics returns the visitors of each variant of the links of the user.
+func (store *PostgresStore) getVariantAnalytics(tx *sqlx.Tx, conf analytics.Config, time, filter string, args []interface{}) ([]analytics.StatsVariantMode, error) {
+	var stats []analytics.StatsVariantMode
+	if err := tx.Select(&stats, dimensionQuery(conf, time, filter, "variant"), args...); err != nil {
 		return nil, err
 	}
 	return stats, nil
 }
+
+// getSourceAnalytics returns the visitors of each source, like QR codes, of the links of the user.
+func (store *PostgresStore) getSourceAnalytics(tx *sqlx.Tx, conf analytics.Config, time, filter string, args []interface{}) ([]analytics.StatsSourceMode, error) {
+	var stats []analytics.StatsSourceMode
+	if err := tx.Select(&stats, dimensionQuery(conf, time, filter, "source"), args...); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// getUTMAnalytics returns the visitors of each combination of campaign parameters of the links of the user.
+func (store *PostgresStore) getUTMAnalytics(tx *sqlx.Tx, conf analytics.Config, time, filter string, args []interface{}) ([]analytics.StatsUTMMode, error) {
+	visitors := "count(h.fingerprint)"
+	if conf.Unique {
+		visitors = "count(distinct h.fingerprint)"
+	}
+	query := `SELECT l.utm_source, l.utm_medium, l.utm_campaign, l.utm_term, l.utm_content, ` + visitors + ` as visitors from users
+		inner join user_links ul on ul.user_id = users.user_id
+		inner join links l on l.link_id = ul.link_id
+		inner join hit h on h.path = l.shortner_path
+		where users.user_id = $1 and h.time > CURRENT_DATE ` + time + filter + `
+		and (l.utm_source <> '' or l.utm_medium <> '' or l.utm_campaign <> '' or l.utm_term <> '' or l.utm_content <> '')
+		group by l.utm_source, l.utm_medium, l.utm_campaign, l.utm_term, l.utm_content
+		order by l.utm_source, l.utm_medium, l.utm_campaign, l.utm_term, l.utm_content`
+	var stats []analytics.StatsUTMMode
+	if err := tx.Select(&stats, query, args...); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// analyticsFilter returns the conditions limiting the analytics to the links of a tag or a folder, with the query arguments.
+func analyticsFilter(conf analytics.Config, userID int) (string, []interface{}) {
+	args := []interface{}{userID}
+	filter := ""
+	if conf.Tag != "" {
+		args = append(args, conf.Tag)
+		filter += fmt.Sprintf(` and l.link_id in (select lt.link_id from link_tags lt inner join tags t on t.tag_id = lt.tag_id
+			where t.user_id = $1 and t.name = $%d)`, len(args))
+	}
+	if conf.FolderID != 0 {
+		args = append(args, conf.FolderID)
+		filter += fmt.Sprintf(` and l.folder_id = $%d`, len(args))
+	}
+	utm := [][2]string{
+		{"utm_source", conf.UTM.Source},
+		{"utm_medium", conf.UTM.Medium},
+		{"utm_campaign", conf.UTM.Campaign},
+		{"utm_term", conf.UTM.Term},
+		{"utm_content", conf.UTM.Content},
+	}
+	for _, param := range utm {
+		if param[1] != "" {
+			args = append(args, param[1])
+			filter += fmt.Sprintf(` and l.%s = $%d`, param[0], len(args))
+		}
+	}
+	return filter, args
+}
+
+// dimensionQuery builds the query counting the visitors of the links of the user by the given hit column.
+func dimensionQuery(conf analytics.Config, time, filter, column string) string {
+	visitors := "count(h.fingerprint)"
+	if conf.Unique {
+		visitors = "count(distinct h.fingerprint)"
+	}
+	return `SELECT h.path, h.` + column + `, ` + visitors + ` as visitors from users
+		inner join user_links ul on ul.user_id = users.user_id
+		inner join links l on l.link_id = ul.link_id
+		inner join hit h on h.path = l.shortner_path
+		where users.user_id = $1 and h.` + column + ` is not null and h.time > CURRENT_DATE ` + time + filter + `
+		group by h.path, h.` + column + `
+		order by h.path, h.` + column
+}
@@ -10,12 +10,21 @@ import (
 )
 
 // Fingerprint returns a hash for given request and salt.
-// The hash is unique for the visitor.
+// The hash is unique for the visitor and changes every day.
 func Fingerprint(r *http.Request, salt string) string {
+	return hashVisitor(r, time.Now().UTC().Format("20060102")+salt)
+}
+
+// VisitorKey returns a hash for given request and salt.
+// Unlike the fingerprint it does not change every day, so the visitor can be recognized over days.
+func VisitorKey(r *http.Request, salt string) string {
+	return hashVisitor(r, salt)
+}
+
+func hashVisitor(r *http.Request, salt string) string {
 	var sb strings.Builder
 	sb.WriteString(r.Header.Get("User-Agent"))
 	sb.WriteString(getIP(r))
-	sb.WriteString(time.Now().UTC().Format("20060102"))
 	sb.WriteString(salt)
 	hash := md5.New()
 
@@ -0,0 +1,29 @@
+package track
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVisitorKey(t *testing.T) {
+	visitor := httptest.NewRequest("GET", "/abc", nil)
+	visitor.RemoteAddr = "203.0.113.1:5000"
+	visitor.Header.Set("User-Agent", "Mozilla/5.0")
+	other := httptest.NewRequest("GET", "/abc", nil)
+	other.RemoteAddr = "203.0.113.2:5000"
+	other.Header.Set("User-Agent", "Mozilla/5.0")
+
+	if VisitorKey(visitor, "salt") != VisitorKey(visitor, "salt") {
+		t.Error("got different keys for the same visitor")
+	}
+	if VisitorKey(visitor, "salt") == VisitorKey(other, "salt") {
+		t.Error("got the same key for different visitors")
+	}
+	if VisitorKey(visitor, "salt") == VisitorKey(visitor, "pepper") {
+		t.Error("got the same key for different salts")
+	}
+	// the fingerprint changes every day, the key must not be one
+	if VisitorKey(visitor, "salt") == Fingerprint(visitor, "salt") {
+		t.Error("got the fingerprint as key")
+	}
+}
@@ -0,0 +1,60 @@
+package track
+
+import (
+	"github.com/oschwald/maxminddb-golang"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// GeoDB maps IPs to their geo location based on a local MaxMind (.mmdb) database, like GeoLite2 Country.
+type GeoDB struct {
+	db *maxminddb.Reader
+}
+
+type geoInfo struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// NewGeoDB opens the MaxMind database at given path.
+// Make sure you call Close when the database is no longer needed.
+func NewGeoDB(path string) (*GeoDB, error) {
+	db, err := maxminddb.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoDB{db: db}, nil
+}
+
+// Close closes the database file handle.
+func (db *GeoDB) Close() error {
+	return db.db.Close()
+}
+
+// CountryCode looks up the country code for given IP.
+// If the IP is invalid or cannot be found, an empty string will be returned.
+// The country code is returned in upper case (ISO 3166-1 alpha-2).
+func (db *GeoDB) CountryCode(ip string) string {
+	parsedIP := net.ParseIP(ip)
+
+	if parsedIP == nil {
+		return ""
+	}
+
+	var info geoInfo
+
+	if err := db.db.Lookup(parsedIP, &info); err != nil {
+		return ""
+	}
+
+	return strings.ToUpper(info.Country.ISOCode)
+}
+
+// RequestCountryCode looks up the country code for the client IP of given request.
+func (db *GeoDB) RequestCountryCode(r *http.Request) string {
+	return db.CountryCode(getIP(r))
+}
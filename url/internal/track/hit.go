@@ -49,6 +49,9 @@ type Hit struct {
 	ScreenWidth    int            `db:"screen_width" json:"screen_width"`
 	ScreenHeight   int            `db:"screen_height" json:"screen_height"`
 	ScreenClass    sql.NullString `db:"screen_class" json:"screen_class"`
+	Target         sql.NullString `db:"target" json:"target,omitempty"`
+	Variant        sql.NullString `db:"variant" json:"variant,omitempty"`
+	Source         sql.NullString `db:"source" json:"source,omitempty"`
 	Time           time.Time      `db:"time" json:"time"`
 }
 
@@ -85,7 +88,16 @@ type HitOptions struct {
 	// ScreenHeight sets the screen height to be stored with the hit.
 	ScreenHeight int
 
-	//geoDB        *GeoDB
+	// Target is the targeting rule which chose the destination of the visitor.
+	Target string
+
+	// Variant is the name of the weighted destination chosen for the visitor.
+	Variant string
+
+	// Source tells where the visitor comes from, like a QR code.
+	Source string
+
+	geoDB *GeoDB
 	//sessionCache *sessionCache
 }
 
@@ -115,11 +127,14 @@ func HitFromRequest(r *http.Request, salt string, options *HitOptions) Hit {
 	lang := shortenString(getLanguage(r), 10)
 	//referrer := shortenString(getReferrer(r, options.Referrer, options.ReferrerDomainBlacklist, options.ReferrerDomainBlacklistIncludesSubdomains), 200)
 	screen := GetScreenClass(options.ScreenWidth)
+	target := shortenString(options.Target, 200)
+	variant := shortenString(options.Variant, 50)
+	source := shortenString(options.Source, 20)
 	countryCode := ""
 
-	//if options.geoDB != nil {
-	//	countryCode = options.geoDB.CountryCode(getIP(r))
-	//}
+	if options.geoDB != nil {
+		countryCode = options.geoDB.CountryCode(getIP(r))
+	}
 
 	var session time.Time
 
@@ -155,6 +170,9 @@ func HitFromRequest(r *http.Request, salt string, options *HitOptions) Hit {
 		ScreenWidth:    options.ScreenWidth,
 		ScreenHeight:   options.ScreenHeight,
 		ScreenClass:    sql.NullString{String: screen, Valid: screen != ""},
+		Target:         sql.NullString{String: target, Valid: target != ""},
+		Variant:        sql.NullString{String: variant, Valid: variant != ""},
+		Source:         sql.NullString{String: source, Valid: source != ""},
 		Time:           now,
 	}
 }
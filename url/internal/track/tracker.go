@@ -87,6 +87,7 @@ type Tracker struct {
 	workerDone                                chan bool
 	referrerDomainBlacklist                   []string
 	referrerDomainBlacklistIncludesSubdomains bool
+	geoDB                                     *GeoDB
 	geoDBMutex                                sync.RWMutex
 	logger                                    log.Logger
 }
@@ -135,10 +136,22 @@ func (tracker *Tracker) Hit(r *http.Request, options *HitOptions) {
 			}
 		}
 
+		tracker.geoDBMutex.RLock()
+		options.geoDB = tracker.geoDB
+		tracker.geoDBMutex.RUnlock()
 		tracker.hits <- HitFromRequest(r, tracker.salt, options)
 	}
 }
 
+// SetGeoDB sets the GeoDB for the Tracker.
+// The call to this function is thread safe to enable live updates of the database.
+// Pass nil to disable the feature.
+func (tracker *Tracker) SetGeoDB(geoDB *GeoDB) {
+	tracker.geoDBMutex.Lock()
+	defer tracker.geoDBMutex.Unlock()
+	tracker.geoDB = geoDB
+}
+
 // Flush flushes all hits to store that are currently buffered by the workers.
 // Call Tracker.Stop to also save hits that are in the queue.
 func (tracker *Tracker) Flush() {
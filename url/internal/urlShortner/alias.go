@@ -0,0 +1,45 @@
+package urlShortner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"url/internal/errors"
+)
+
+const (
+	minAliasLength = 3
+	maxAliasLength = 50
+
+	// aliasSuggestions is the number of free alternatives offered for a taken alias.
+	aliasSuggestions = 3
+)
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// reservedAliases can not be claimed, because they are used by routes of the server.
+var reservedAliases = []string{
+	"admin",
+	"api",
+	"healthcheck",
+	"links",
+	"static",
+}
+
+type aliasConflictDetails struct {
+	Suggestions []string `json:"suggestions"`
+}
+
+// validateAlias checks the alias has a valid length and characters and is not reserved.
+func validateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return errors.BadRequest(fmt.Sprintf("alias must be between %d and %d characters", minAliasLength, maxAliasLength))
+	}
+	if !aliasPattern.MatchString(alias) {
+		return errors.BadRequest("alias may only contain letters, digits, '-' and '_'")
+	}
+	if containsString(reservedAliases, strings.ToLower(alias)) {
+		return errors.BadRequest(fmt.Sprintf("alias %s is reserved", alias))
+	}
+	return nil
+}
@@ -1,8 +1,14 @@
 package urlShortner
 
 import (
+	"fmt"
 	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"html/template"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"url/internal/config"
 	"url/internal/errors"
 	"url/pkg/log"
 )
@@ -13,21 +19,80 @@ var (
 )
 
 type resource struct {
-	service Service
-	logger  log.Logger
+	service   Service
+	errorPage *template.Template
+	logger    log.Logger
 }
 
 type Response struct {
 	Message string `json:"data"`
 }
 
+type unlockRequest struct {
+	Password string `json:"password" form:"password"`
+}
+
 // RegisterHandlers sets up the routing of the HTTP handlers.
-func RegisterHandlers(r *routing.RouteGroup, service Service, logger log.Logger, authHandler routing.Handler) {
-	res := resource{service, logger}
-	r.Get("<shortLink>", res.redirect)
+// The error page is shown to browsers visiting links which cannot be followed, if their owner has no fallback.
+func RegisterHandlers(r *routing.RouteGroup, service Service, errorPage *template.Template, logger log.Logger, authHandler routing.Handler) {
+	res := resource{service, errorPage, logger}
+	r.Get("/<shortLink>", res.redirect)
+	r.Post("/<shortLink>", res.unlock)
+	r.Post("/api/v1/reports", res.report)
 
 	r.Use(authHandler)
 	r.Post("/api/v1/encode", res.encode)
+	r.Post("/api/v1/encode/batch", res.encodeBatch)
+
+	// routes related to managing the links of the user
+	r.Get("/api/v1/links", res.list)
+	r.Post("/api/v1/links/import", res.importCSV)
+	r.Post("/api/v1/links/transfer", res.transferAll)
+	r.Get("/api/v1/links/export", res.exportCSV)
+	r.Get("/api/v1/links/<code>", res.get)
+	r.Get("/api/v1/links/<code>/qr", res.qr)
+	r.Get("/api/v1/links/<code>/history", res.history)
+	r.Put("/api/v1/links/<code>/og", res.setOG)
+	r.Patch("/api/v1/links/<code>", res.update)
+	r.Delete("/api/v1/links/<code>", res.delete)
+	r.Put("/api/v1/links/<code>/tags", res.setLinkTags)
+	r.Put("/api/v1/links/<code>/folder", res.setLinkFolder)
+	r.Post("/api/v1/links/<code>/disable", res.disable)
+	r.Post("/api/v1/links/<code>/enable", res.enable)
+	r.Post("/api/v1/links/<code>/transfer", res.transfer)
+	r.Post("/api/v1/links/<code>/clone", res.clone)
+
+	// routes related to the deleted links of the user
+	r.Get("/api/v1/trash", res.listTrash)
+	r.Post("/api/v1/trash/<code>/restore", res.restore)
+	r.Delete("/api/v1/trash/<code>", res.purge)
+
+	// routes related to organizing the links of the user
+	r.Get("/api/v1/tags", res.listTags)
+	r.Post("/api/v1/tags", res.createTag)
+	r.Patch("/api/v1/tags/<id>", res.renameTag)
+	r.Delete("/api/v1/tags/<id>", res.deleteTag)
+	r.Get("/api/v1/folders", res.listFolders)
+	r.Post("/api/v1/folders", res.createFolder)
+	r.Patch("/api/v1/folders/<id>", res.renameFolder)
+	r.Delete("/api/v1/folders/<id>", res.deleteFolder)
+
+	// routes related to the branded domains of the user
+	r.Get("/api/v1/domains", res.listDomains)
+	r.Post("/api/v1/domains", res.addDomain)
+	r.Post("/api/v1/domains/<id>/verify", res.verifyDomain)
+	r.Delete("/api/v1/domains/<id>", res.deleteDomain)
+
+	// routes related to the settings of the user
+	r.Get("/api/v1/settings/fallback", res.getFallback)
+	r.Put("/api/v1/settings/fallback", res.setFallback)
+
+	// routes related to reviewing abuse, only for admins
+	r.Get("/api/v1/admin/reports", res.requireAdmin, res.listReports)
+	r.Post("/api/v1/admin/reports/<id>/dismiss", res.requireAdmin, res.dismissReport)
+	r.Post("/api/v1/admin/links/<code>/ban", res.requireAdmin, res.ban)
+	r.Post("/api/v1/admin/links/<code>/unban", res.requireAdmin, res.unban)
+	r.Post("/api/v1/admin/transfers", res.requireAdmin, res.adminTransfer)
 }
 
 func (res resource) encode(c *routing.Context) error {
@@ -43,12 +108,604 @@ func (res resource) encode(c *routing.Context) error {
 	return c.Write(Response{Message: url})
 }
 
+func (res resource) encodeBatch(c *routing.Context) error {
+	var input []InputDTO
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	results, err := res.service.BatchEnCode(c.Request.Context(), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(results)
+}
+
+// redirect sends the visitor to the destination of the link.
+// The same code can be used on several domains, so it is looked up on the host of the request.
 func (res resource) redirect(c *routing.Context) error {
-	path := c.Param("shortLink")
-	uri, err := res.service.Load(c.Request, path)
+	path, err := res.service.Scope(c.Request, strings.TrimSuffix(c.Param("shortLink"), previewSuffix))
+	if err != nil {
+		return res.fail(c, c.Param("shortLink"), err)
+	}
+	if strings.HasSuffix(c.Param("shortLink"), previewSuffix) {
+		return res.preview(c, path)
+	}
+	if isCrawler(c.Request.UserAgent()) {
+		return res.unfurl(c, path)
+	}
+	dest, err := res.service.Load(c.Request, path)
+	if err == ErrPasswordRequired {
+		return renderPage(c.Response, http.StatusUnauthorized, passwordPage, passwordPageData{})
+	} else if err != nil {
+		return res.fail(c, path, err)
+	}
+	if dest.Interstitial != nil {
+		return renderPage(c.Response, http.StatusOK, previewPage, previewPageData{*dest.Interstitial, interstitialDelay})
+	}
+	http.Redirect(c.Response, c.Request, dest.URL, dest.Status)
+	return nil
+}
+
+// unfurl serves the OpenGraph metadata of the link to the apps showing shared links, they are not redirected.
+func (res resource) unfurl(c *routing.Context, code string) error {
+	preview, err := res.service.Preview(c.Request.Context(), code)
+	if err != nil {
+		return res.fail(c, code, err)
+	}
+	return renderPage(c.Response, http.StatusOK, unfurlPage, preview)
+}
+
+// preview shows where the link goes, as a page for browsers and as json for API clients.
+func (res resource) preview(c *routing.Context, code string) error {
+	preview, err := res.service.Preview(c.Request.Context(), code)
+	if err != nil {
+		return res.fail(c, code, err)
+	}
+	if !wantsHTML(c.Request) {
+		return c.Write(preview)
+	}
+	return renderPage(c.Response, http.StatusOK, previewPage, previewPageData{Preview: preview})
+}
+
+func (res resource) unlock(c *routing.Context) error {
+	path, err := res.service.Scope(c.Request, c.Param("shortLink"))
+	if err != nil {
+		return res.fail(c, c.Param("shortLink"), err)
+	}
+	input := unlockRequest{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	dest, cookie, err := res.service.Unlock(c.Request, path, input.Password)
+	if e, ok := err.(errors.ErrorResponse); ok && e.Status == http.StatusUnauthorized {
+		return renderPage(c.Response, http.StatusUnauthorized, passwordPage, passwordPageData{Error: e.Message})
+	} else if err != nil {
+		return res.fail(c, path, err)
+	}
+	if cookie != nil {
+		http.SetCookie(c.Response, cookie)
+	}
+	if dest.Interstitial != nil {
+		return renderPage(c.Response, http.StatusOK, previewPage, previewPageData{*dest.Interstitial, interstitialDelay})
+	}
+	// the form is posted, so the destination is always fetched with a GET
+	http.Redirect(c.Response, c.Request, dest.URL, http.StatusSeeOther)
+	return nil
+}
+
+// fail answers a visitor of a link which cannot be followed.
+// API clients get the error as json, browsers are sent to the fallback url or see the error page.
+// The fallback of the owner of the link takes precedence over the one of the server.
+func (res resource) fail(c *routing.Context, code string, err error) error {
+	e, ok := err.(errors.ErrorResponse)
+	if !ok {
+		e = errors.NotFound(err.Error())
+	}
+	if !wantsHTML(c.Request) {
+		return e
+	}
+	// the owner of a banned link is not trusted with its visitors
+	if err == ErrBanned {
+		code, _ = splitCode(code)
+		return renderPage(c.Response, e.Status, bannedPage, errorPageData{Code: code, Status: e.Status, Message: e.Message})
+	}
+	fallback, err := res.service.CodeFallback(c.Request.Context(), code)
 	if err != nil {
-		return errors.NotFound(err.Error())
+		res.logger.With(c.Request.Context()).Errorf("failed loading the fallback of %s: %s", code, err)
+	}
+	page := res.errorPage
+	if fallback.URL == "" && fallback.Template != "" {
+		if page, err = template.New("error").Parse(fallback.Template); err != nil {
+			return e
+		}
+	} else if fallback.URL == "" {
+		fallback.URL = config.Cfg.NotFound.URL
+	}
+	if fallback.URL != "" {
+		http.Redirect(c.Response, c.Request, fallback.URL, http.StatusFound)
+		return nil
+	}
+	c.Response.Header().Set("Content-Security-Policy", errorPageCSP)
+	code, _ = splitCode(code)
+	return renderPage(c.Response, e.Status, page, errorPageData{Code: code, Status: e.Status, Message: e.Message})
+}
+
+func (res resource) list(c *routing.Context) error {
+	page, err := res.service.List(c.Request.Context(), listQueries{
+		Page:    c.Query("page", "1"),
+		PerPage: c.Query("per_page", strconv.Itoa(defaultPerPage)),
+		Search:  c.Query("q"),
+		From:    c.Query("from"),
+		To:      c.Query("to"),
+		Tag:     c.Query("tag"),
+		Folder:  c.Query("folder"),
+	}, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(page)
+}
+
+func (res resource) importCSV(c *routing.Context) error {
+	// the csv file is either uploaded as the file field of a form or sent as the body
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			res.logger.With(c.Request.Context()).Info(err)
+			return errors.BadRequest("the csv file must be uploaded in the file field")
+		}
+		defer file.Close()
+		body = file
+	}
+	results, err := res.service.Import(c.Request.Context(), body, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(results)
+}
+
+func (res resource) exportCSV(c *routing.Context) error {
+	c.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	c.Response.Header().Set("Content-Disposition", `attachment; filename="links.csv"`)
+	if err := res.service.Export(c.Request.Context(), c.Response, c.Get("user_id").(int)); err != nil {
+		// the response has already started, so the error can only be logged
+		res.logger.With(c.Request.Context()).Errorf("failed exporting links: %s", err)
 	}
-	http.Redirect(c.Response, c.Request, uri, http.StatusMovedPermanently)
 	return nil
 }
+
+func (res resource) qr(c *routing.Context) error {
+	image, err := res.service.QR(c.Request.Context(), c.Param("code"), qrQueries{
+		Format:     c.Query("format", "png"),
+		Size:       c.Query("size", strconv.Itoa(defaultQRSize)),
+		Margin:     c.Query("margin", strconv.Itoa(defaultQRMargin)),
+		Level:      c.Query("level", "M"),
+		Foreground: c.Query("fg", "000000"),
+		Background: c.Query("bg", "ffffff"),
+	}, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	c.Response.Header().Set("Content-Type", image.ContentType)
+	_, err = c.Response.Write(image.Data)
+	return err
+}
+
+func (res resource) get(c *routing.Context) error {
+	link, err := res.service.Get(c.Request.Context(), c.Param("code"), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) update(c *routing.Context) error {
+	input := UpdateDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	link, err := res.service.Update(c.Request.Context(), c.Param("code"), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) setOG(c *routing.Context) error {
+	input := OpenGraph{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	link, err := res.service.SetOG(c.Request.Context(), c.Param("code"), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) history(c *routing.Context) error {
+	changes, err := res.service.History(c.Request.Context(), c.Param("code"), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(changes)
+}
+
+func (res resource) delete(c *routing.Context) error {
+	if err := res.service.Delete(c.Request.Context(), c.Param("code"), c.Get("user_id").(int)); err != nil {
+		return err
+	}
+	return c.Write(Response{Message: SuccessfulResponse})
+}
+
+func (res resource) listTrash(c *routing.Context) error {
+	page, err := res.service.ListTrash(c.Request.Context(), listQueries{
+		Page:    c.Query("page", "1"),
+		PerPage: c.Query("per_page", strconv.Itoa(defaultPerPage)),
+		Search:  c.Query("q"),
+		From:    c.Query("from"),
+		To:      c.Query("to"),
+		Tag:     c.Query("tag"),
+		Folder:  c.Query("folder"),
+	}, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(page)
+}
+
+func (res resource) restore(c *routing.Context) error {
+	link, err := res.service.Restore(c.Request.Context(), c.Param("code"), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) purge(c *routing.Context) error {
+	if err := res.service.Purge(c.Request.Context(), c.Param("code"), c.Get("user_id").(int)); err != nil {
+		return err
+	}
+	return c.Write(Response{Message: SuccessfulResponse})
+}
+
+func (res resource) setLinkTags(c *routing.Context) error {
+	input := LinkTagsDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	link, err := res.service.SetLinkTags(c.Request.Context(), c.Param("code"), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) setLinkFolder(c *routing.Context) error {
+	input := LinkFolderDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	link, err := res.service.SetLinkFolder(c.Request.Context(), c.Param("code"), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) listTags(c *routing.Context) error {
+	tags, err := res.service.ListTags(c.Request.Context(), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(tags)
+}
+
+func (res resource) createTag(c *routing.Context) error {
+	input := TagDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	tag, err := res.service.CreateTag(c.Request.Context(), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.WriteWithStatus(tag, http.StatusCreated)
+}
+
+func (res resource) renameTag(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	input := TagDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	tag, err := res.service.RenameTag(c.Request.Context(), id, input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(tag)
+}
+
+func (res resource) deleteTag(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	if err := res.service.DeleteTag(c.Request.Context(), id, c.Get("user_id").(int)); err != nil {
+		return err
+	}
+	return c.Write(Response{Message: SuccessfulResponse})
+}
+
+func (res resource) listFolders(c *routing.Context) error {
+	folders, err := res.service.ListFolders(c.Request.Context(), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(folders)
+}
+
+func (res resource) createFolder(c *routing.Context) error {
+	input := FolderDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	folder, err := res.service.CreateFolder(c.Request.Context(), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.WriteWithStatus(folder, http.StatusCreated)
+}
+
+func (res resource) renameFolder(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	input := FolderDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	folder, err := res.service.RenameFolder(c.Request.Context(), id, input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(folder)
+}
+
+func (res resource) deleteFolder(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	if err := res.service.DeleteFolder(c.Request.Context(), id, c.Get("user_id").(int)); err != nil {
+		return err
+	}
+	return c.Write(Response{Message: SuccessfulResponse})
+}
+
+func (res resource) listDomains(c *routing.Context) error {
+	domains, err := res.service.ListDomains(c.Request.Context(), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(domains)
+}
+
+func (res resource) addDomain(c *routing.Context) error {
+	input := DomainDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	domain, err := res.service.AddDomain(c.Request.Context(), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.WriteWithStatus(domain, http.StatusCreated)
+}
+
+func (res resource) verifyDomain(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	domain, err := res.service.VerifyDomain(c.Request.Context(), id, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(domain)
+}
+
+func (res resource) deleteDomain(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	if err := res.service.DeleteDomain(c.Request.Context(), id, c.Get("user_id").(int)); err != nil {
+		return err
+	}
+	return c.Write(Response{Message: SuccessfulResponse})
+}
+
+// report records an abuse report, anyone can report a link.
+func (res resource) report(c *routing.Context) error {
+	input := ReportDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	if err := res.service.Report(c.Request, input); err != nil {
+		return err
+	}
+	return c.WriteWithStatus(Response{Message: SuccessfulResponse}, http.StatusAccepted)
+}
+
+func (res resource) disable(c *routing.Context) error {
+	link, err := res.service.Disable(c.Request.Context(), c.Param("code"), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) enable(c *routing.Context) error {
+	link, err := res.service.Enable(c.Request.Context(), c.Param("code"), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) transfer(c *routing.Context) error {
+	input := TransferDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	result, err := res.service.Transfer(c.Request.Context(), c.Param("code"), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(result)
+}
+
+func (res resource) transferAll(c *routing.Context) error {
+	input := TransferDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	result, err := res.service.TransferAll(c.Request.Context(), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(result)
+}
+
+func (res resource) clone(c *routing.Context) error {
+	// the options are optional, so the body may be empty
+	input := CloneDTO{}
+	if err := c.Read(&input); err != nil && err != io.EOF {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	link, err := res.service.Clone(c.Request.Context(), c.Param("code"), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.WriteWithStatus(link, http.StatusCreated)
+}
+
+func (res resource) adminTransfer(c *routing.Context) error {
+	input := AdminTransferDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	result, err := res.service.AdminTransfer(c.Request.Context(), input)
+	if err != nil {
+		return err
+	}
+	return c.Write(result)
+}
+
+// requireAdmin lets only admins use the following handlers.
+func (res resource) requireAdmin(c *routing.Context) error {
+	admin, err := res.service.IsAdmin(c.Request.Context(), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return errors.Forbidden("")
+	}
+	return nil
+}
+
+func (res resource) listReports(c *routing.Context) error {
+	page, err := res.service.ListReports(c.Request.Context(), reportQueries{
+		Page:     c.Query("page", "1"),
+		PerPage:  c.Query("per_page", strconv.Itoa(defaultPerPage)),
+		Reviewed: c.Query("reviewed", "false"),
+	})
+	if err != nil {
+		return err
+	}
+	return c.Write(page)
+}
+
+func (res resource) dismissReport(c *routing.Context) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+	if err := res.service.DismissReport(c.Request.Context(), id); err != nil {
+		return err
+	}
+	return c.Write(Response{Message: SuccessfulResponse})
+}
+
+func (res resource) ban(c *routing.Context) error {
+	input := BanDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	link, err := res.service.Ban(c.Request.Context(), c.Param("code"), input)
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+func (res resource) unban(c *routing.Context) error {
+	link, err := res.service.Unban(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		return err
+	}
+	return c.Write(link)
+}
+
+// idParam reads the numeric id of the route.
+func idParam(c *routing.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		return 0, errors.BadRequest(fmt.Sprintf("enter the correct id, %s is not an id", c.Param("id")))
+	}
+	return id, nil
+}
+
+func (res resource) getFallback(c *routing.Context) error {
+	fallback, err := res.service.GetFallback(c.Request.Context(), c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(fallback)
+}
+
+func (res resource) setFallback(c *routing.Context) error {
+	input := FallbackDTO{}
+	if err := c.Read(&input); err != nil {
+		res.logger.With(c.Request.Context()).Info(err)
+		return errors.BadRequest("")
+	}
+	fallback, err := res.service.SetFallback(c.Request.Context(), input, c.Get("user_id").(int))
+	if err != nil {
+		return err
+	}
+	return c.Write(fallback)
+}
@@ -0,0 +1,118 @@
+package urlShortner
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"url/internal/errors"
+)
+
+// maxBatchSize is the maximum number of links created by a single batch request.
+const maxBatchSize = 1000
+
+// BatchResult is the outcome of a single item of a batch.
+type BatchResult struct {
+	Index int                   `json:"index"`
+	URL   string                `json:"url,omitempty"`
+	Error *errors.ErrorResponse `json:"error,omitempty"`
+}
+
+func (s service) BatchEnCode(ctx context.Context, reqs []InputDTO, userID int) ([]BatchResult, error) {
+	if len(reqs) == 0 || len(reqs) > maxBatchSize {
+		return nil, errors.BadRequest(fmt.Sprintf("a batch must have between 1 and %d links", maxBatchSize))
+	}
+	results := make([]BatchResult, len(reqs))
+	links := make([]Link, len(reqs))
+	batch := make([]BatchItem, 0, len(reqs))
+	positions := make([]int, 0, len(reqs))
+	// plain links to the same url share the same code, like they do across requests
+	duplicates := make(map[int]int)
+	plain := make(map[string]int)
+
+	for i, req := range reqs {
+		results[i].Index = i
+		item, link, existing, err := s.prepare(ctx, req, userID)
+		if err != nil {
+			results[i].Error = batchError(err)
+			continue
+		}
+		if existing != "" {
+			results[i].URL = shortURL(existing)
+			continue
+		}
+		if !req.ForceNew && req.isPlain() {
+			if j, ok := plain[link.CanonicalURL]; ok {
+				duplicates[i] = j
+				continue
+			}
+			plain[link.CanonicalURL] = i
+		}
+		links[i] = link
+		batch = append(batch, BatchItem{Item: item, Alias: req.Alias, SimilarTo: req.SimilarTo, Domain: link.Domain})
+		positions = append(positions, i)
+	}
+
+	codes, errs := s.repo.CreateBatch(ctx, batch)
+	created := make([]Link, 0, len(positions))
+	createdAt := make([]int, 0, len(positions))
+	tx := s.store.NewTx()
+	for j, i := range positions {
+		if errs[j] == errAliasTaken {
+			results[i].Error = batchError(s.aliasConflict(ctx, batch[j].Alias, batch[j].Domain))
+			continue
+		} else if errs[j] != nil {
+			results[i].Error = batchError(errs[j])
+			continue
+		}
+		links[i].Code = codes[j]
+		if err := s.createLinkInBatch(tx, userID, links[i]); err != nil {
+			// the code is not saved, so it must not resolve either
+			if err := s.repo.Delete(ctx, codes[j]); err != nil {
+				s.logger.With(ctx).Errorf("failed deleting code %s of a failed batch item: %s", codes[j], err)
+			}
+			results[i].Error = batchError(s.codeError(ctx, err, batch[j].Alias, batch[j].Domain))
+			continue
+		}
+		results[i].URL = shortURL(codes[j])
+		created = append(created, links[i])
+		createdAt = append(createdAt, i)
+	}
+	// the links of the batch are only saved with the commit, which must not fail silently
+	if commitErr := tx.Commit(); commitErr != nil {
+		s.logger.With(ctx).Errorf("failed committing a batch: %s", commitErr)
+		for k, link := range created {
+			if err := s.repo.Delete(ctx, link.Code); err != nil {
+				s.logger.With(ctx).Errorf("failed deleting code %s of a failed batch: %s", link.Code, err)
+			}
+			results[createdAt[k]].URL, results[createdAt[k]].Error = "", batchError(commitErr)
+		}
+		created = nil
+	}
+	s.fetchMetadata(created)
+
+	for i, j := range duplicates {
+		results[i].URL, results[i].Error = results[j].URL, results[j].Error
+	}
+	return results, nil
+}
+
+// createLinkInBatch saves the link in the transaction of the batch.
+// A failing link is rolled back alone, so the other links of the batch are still saved.
+func (s service) createLinkInBatch(tx *sqlx.Tx, userID int, link Link) error {
+	if err := s.store.Savepoint(tx, "batch_item"); err != nil {
+		return err
+	}
+	if err := s.saveLink(tx, userID, link); err != nil {
+		if rollbackErr := s.store.RollbackToSavepoint(tx, "batch_item"); rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+	return nil
+}
+
+// batchError converts the error of a batch item into the same response a single request would get.
+func batchError(err error) *errors.ErrorResponse {
+	res := errors.BuildErrorResponse(err)
+	return &res
+}
@@ -0,0 +1,60 @@
+package urlShortner
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// passwordCookieAge is how long a visitor who entered the password of a link is not asked again.
+const passwordCookieAge = time.Hour
+
+// newPasswordCookie creates a signed cookie which proves the password of the link was entered.
+// The password hash is part of the signature, so changing the password invalidates the cookie.
+// Cookies belong to the host, so the cookie of a code scoped by a branded domain is named and placed by the bare code.
+func newPasswordCookie(code, passwordHash, secret string) *http.Cookie {
+	expires := time.Now().Add(passwordCookieAge)
+	value := strconv.FormatInt(expires.Unix(), 10)
+	path, _ := splitCode(code)
+	return &http.Cookie{
+		Name:     passwordCookieName(code),
+		Value:    value + "." + sign(secret, code, passwordHash, value),
+		Path:     "/" + path,
+		Expires:  expires,
+		MaxAge:   int(passwordCookieAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// validPasswordCookie checks the request has a valid and not expired password cookie for the link.
+func validPasswordCookie(r *http.Request, code, passwordHash, secret string) bool {
+	cookie, err := r.Cookie(passwordCookieName(code))
+	if err != nil {
+		return false
+	}
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(parts[1]), []byte(sign(secret, code, passwordHash, parts[0])))
+}
+
+func passwordCookieName(code string) string {
+	code, _ = splitCode(code)
+	return "shorti_" + code
+}
+
+func sign(secret string, values ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(values, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,148 @@
+package urlShortner
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"url/internal/errors"
+)
+
+const (
+	// maxImportRows is the maximum number of links imported from a single csv file.
+	maxImportRows = 20000
+
+	// csvTagSeparator separates the tags of a link in a csv cell.
+	csvTagSeparator = ";"
+)
+
+var exportHeader = []string{"code", "short_url", "url", "canonical_url", "expires_at", "max_clicks", "tags", "created_at", "clicks"}
+
+// Import creates the links of the csv file in batches.
+// The file needs a header row with a url column, the alias, expires_at, tags and domain columns are optional.
+// The index of each result is the index of the row, not counting the header.
+func (s service) Import(ctx context.Context, r io.Reader, userID int) ([]BatchResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.BadRequest("the csv file is empty")
+	} else if err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.BadRequest("the csv file has no url column")
+	}
+	// csv files may have rows of different length, missing cells are empty
+	reader.FieldsPerRecord = -1
+
+	var results []BatchResult
+	for done := false; !done; {
+		var inputs []InputDTO
+		var positions []int
+		for len(inputs) < maxBatchSize {
+			record, err := reader.Read()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			index := len(results)
+			if index >= maxImportRows {
+				return nil, errors.BadRequest(fmt.Sprintf("a csv file can have at most %d links", maxImportRows))
+			}
+			results = append(results, BatchResult{Index: index})
+			if err != nil {
+				results[index].Error = batchError(errors.BadRequest(err.Error()))
+				continue
+			}
+			input, err := inputFromRecord(columns, record)
+			if err != nil {
+				results[index].Error = batchError(err)
+				continue
+			}
+			inputs = append(inputs, input)
+			positions = append(positions, index)
+		}
+		if len(inputs) == 0 {
+			continue
+		}
+		batchResults, err := s.BatchEnCode(ctx, inputs, userID)
+		if err != nil {
+			return nil, err
+		}
+		for j, result := range batchResults {
+			result.Index = positions[j]
+			results[positions[j]] = result
+		}
+	}
+	return results, nil
+}
+
+// Export writes all the links of the user with their clicks as csv.
+// The links are streamed from the database, so the export does not load them all into memory.
+func (s service) Export(ctx context.Context, w io.Writer, userID int) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+	err := s.store.StreamUserLinks(nil, userID, func(link LinkClicks) error {
+		expiresAt, maxClicks := "", ""
+		if link.ExpiresAt != nil {
+			expiresAt = link.ExpiresAt.Format(time.RFC3339)
+		}
+		if link.MaxClicks != nil {
+			maxClicks = strconv.FormatInt(*link.MaxClicks, 10)
+		}
+		return writer.Write([]string{
+			link.Code,
+			shortURL(link.Code),
+			link.URL,
+			link.CanonicalURL,
+			expiresAt,
+			maxClicks,
+			strings.Join(link.Tags, csvTagSeparator),
+			link.CreatedAt.Format(time.RFC3339),
+			strconv.Itoa(link.Clicks),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// inputFromRecord reads the link of a csv row.
+func inputFromRecord(columns map[string]int, record []string) (InputDTO, error) {
+	cell := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	input := InputDTO{
+		URL:    cell("url"),
+		Alias:  cell("alias"),
+		Domain: cell("domain"),
+	}
+	if expiresAt := cell("expires_at"); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return InputDTO{}, errors.BadRequest(fmt.Sprintf("enter the correct expires_at, %s is not RFC3339", expiresAt))
+		}
+		input.ExpiresAt = &t
+	}
+	for _, tag := range strings.Split(cell("tags"), csvTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			input.Tags = append(input.Tags, tag)
+		}
+	}
+	return input, nil
+}
@@ -0,0 +1,55 @@
+package urlShortner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"url/internal/errors"
+	"url/pkg/policy"
+)
+
+// checkDestinations returns an error with every destination of the link which the policy does not allow.
+func (s service) checkDestinations(ctx context.Context, req InputDTO) error {
+	var errs []errors.FieldError
+	check := func(field, rawURL string) {
+		if rawURL == "" {
+			return
+		}
+		if v := s.checkDestination(ctx, rawURL); v != nil {
+			errs = append(errs, errors.FieldError{Field: field, Rule: v.Rule, Error: v.Message})
+		}
+	}
+	check("url", req.URL)
+	check("inactive_url", req.InactiveURL)
+	for i, rule := range req.Targets {
+		check(fmt.Sprintf("targets[%d].url", i), rule.URL)
+	}
+	for i, variant := range req.Variants {
+		check(fmt.Sprintf("variants[%d].url", i), variant.URL)
+	}
+	if len(errs) > 0 {
+		return errors.InvalidFields(errs)
+	}
+	return nil
+}
+
+// checkDestination checks the url against the policy.
+// The domains of the server are shorteners too, links to them would redirect in loops.
+func (s service) checkDestination(ctx context.Context, rawURL string) *policy.Violation {
+	if v := s.policy.Check(ctx, rawURL); v != nil {
+		return v
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &policy.Violation{Rule: policy.RuleScheme, Message: err.Error()}
+	}
+	host := policy.Host(u)
+	branded, err := s.repo.HasDomain(ctx, host)
+	if err != nil {
+		s.logger.With(ctx).Errorf("failed checking the domain %s: %s", host, err)
+	}
+	if host == serverHost() || branded {
+		return &policy.Violation{Rule: policy.RuleShortener, Message: fmt.Sprintf("host %s is a domain of this shortener", host)}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package urlShortner
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"url/internal/config"
+	"url/internal/errors"
+	"url/pkg/validators"
+)
+
+const (
+	// domainSeparator separates the code of a link on a branded domain from the domain, as in code@domain.
+	domainSeparator = "@"
+
+	// verificationRecord prefixes the name of the TXT record which proves the user controls a domain.
+	verificationRecord = "_shorti."
+
+	// verificationValue prefixes the token in the value of the TXT record.
+	verificationValue = "shorti-verification="
+)
+
+// Resolver looks up the TXT records of domains, net.DefaultResolver is used by default.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type DomainDTO struct {
+	Name string `json:"name" validate:"required,fqdn,max=253"`
+}
+
+func (s service) ListDomains(ctx context.Context, userID int) ([]Domain, error) {
+	domains, err := s.store.FindUserDomains(nil, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range domains {
+		domains[i].Record = newDomainRecord(domains[i])
+	}
+	return domains, nil
+}
+
+// AddDomain registers the domain for the user, its links are served once it is verified.
+func (s service) AddDomain(ctx context.Context, req DomainDTO, userID int) (Domain, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Domain{}, err
+	}
+	name := normalizeDomain(req.Name)
+	if name == serverHost() {
+		return Domain{}, errors.BadRequest(fmt.Sprintf("domain %s is the domain of the server", name))
+	}
+	token, err := newDomainToken()
+	if err != nil {
+		return Domain{}, err
+	}
+	domainID, err := s.store.CreateDomain(nil, userID, name, token)
+	if isUniqueViolation(err) {
+		return Domain{}, errors.Conflict(fmt.Sprintf("domain %s already exists", name))
+	} else if err != nil {
+		return Domain{}, err
+	}
+	return s.findDomain(domainID, userID)
+}
+
+// VerifyDomain checks the TXT record of the domain holds its token.
+// A domain can be verified by a single user, who then owns the codes on it.
+func (s service) VerifyDomain(ctx context.Context, domainID int, userID int) (Domain, error) {
+	domain, err := s.findDomain(domainID, userID)
+	if err != nil || domain.VerifiedAt != nil {
+		return domain, err
+	}
+	records, err := s.resolver.LookupTXT(ctx, domain.Record.Name)
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		records = nil
+	} else if err != nil {
+		return Domain{}, err
+	}
+	if !containsString(records, domain.Record.Value) {
+		return Domain{}, errors.BadRequest(fmt.Sprintf("the TXT record %s does not contain %s", domain.Record.Name, domain.Record.Value))
+	}
+	tx := s.store.NewTx()
+	err = s.store.VerifyDomain(tx, domain.ID)
+	if isUniqueViolation(err) {
+		s.store.Rollback(tx)
+		return Domain{}, errors.Conflict(fmt.Sprintf("domain %s is already verified by another user", domain.Name))
+	} else if err != nil {
+		s.store.Rollback(tx)
+		return Domain{}, err
+	}
+	if err := s.repo.AddDomain(ctx, domain.Name); err != nil {
+		s.store.Rollback(tx)
+		return Domain{}, err
+	}
+	s.store.Commit(tx)
+	return s.findDomain(domainID, userID)
+}
+
+// DeleteDomain deletes the domain, a domain which still has links, even in the trash, can not be deleted.
+func (s service) DeleteDomain(ctx context.Context, domainID int, userID int) error {
+	domain, err := s.findDomain(domainID, userID)
+	if err != nil {
+		return err
+	}
+	if domain.VerifiedAt != nil {
+		hasLinks, err := s.store.DomainHasLinks(nil, domain.Name)
+		if err != nil {
+			return err
+		} else if hasLinks {
+			return errors.Conflict(fmt.Sprintf("domain %s still has links, purge the deleted ones from the trash", domain.Name))
+		}
+	}
+	tx := s.store.NewTx()
+	if err := s.store.DeleteDomain(tx, userID, domain.ID); err != nil {
+		s.store.Rollback(tx)
+		return err
+	}
+	if domain.VerifiedAt != nil {
+		if err := s.repo.RemoveDomain(ctx, domain.Name); err != nil {
+			s.store.Rollback(tx)
+			return err
+		}
+	}
+	s.store.Commit(tx)
+	return nil
+}
+
+// Scope returns the code of the link requested on the host of the request.
+// On a branded domain the code is scoped by it, on any other host it is a code of the server domain.
+func (s service) Scope(r *http.Request, code string) (string, error) {
+	return s.scope(r.Context(), requestHost(r), code)
+}
+
+func (s service) scope(ctx context.Context, host, code string) (string, error) {
+	if strings.Contains(code, domainSeparator) {
+		return "", errors.NotFound("")
+	}
+	if host == "" || host == serverHost() {
+		return code, nil
+	}
+	branded, err := s.repo.HasDomain(ctx, host)
+	if err != nil {
+		return "", err
+	} else if !branded {
+		return code, nil
+	}
+	return scopeCode(code, host), nil
+}
+
+func (s service) findDomain(domainID int, userID int) (Domain, error) {
+	domain, err := s.store.FindUserDomain(nil, userID, domainID)
+	if err == sql.ErrNoRows {
+		return Domain{}, errors.NotFound("")
+	} else if err != nil {
+		return Domain{}, err
+	}
+	domain.Record = newDomainRecord(domain)
+	return domain, nil
+}
+
+// checkDomain returns the normalized domain if it is a verified domain of the user.
+func (s service) checkDomain(name string, userID int) (string, error) {
+	name = normalizeDomain(name)
+	domain, err := s.store.FindUserDomainByName(nil, userID, name)
+	if err == sql.ErrNoRows {
+		return "", errors.BadRequest(fmt.Sprintf("domain %s is not one of your domains", name))
+	} else if err != nil {
+		return "", err
+	}
+	if domain.VerifiedAt == nil {
+		return "", errors.BadRequest(fmt.Sprintf("domain %s is not verified yet", name))
+	}
+	return domain.Name, nil
+}
+
+func newDomainRecord(domain Domain) DomainRecord {
+	return DomainRecord{
+		Name:  verificationRecord + domain.Name,
+		Value: verificationValue + domain.Token,
+	}
+}
+
+func newDomainToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// scopeCode returns the code of a link on the domain, codes of the server domain are not scoped.
+func scopeCode(code, domain string) string {
+	if domain == "" {
+		return code
+	}
+	return code + domainSeparator + domain
+}
+
+// splitCode returns the code of a link and its domain, which is empty for the server domain.
+func splitCode(scoped string) (string, string) {
+	if i := strings.LastIndex(scoped, domainSeparator); i >= 0 {
+		return scoped[:i], scoped[i+1:]
+	}
+	return scoped, ""
+}
+
+// requestHost returns the host of the request without its port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return normalizeDomain(host)
+}
+
+// serverHost returns the host of the short urls of the server without its port.
+func serverHost() string {
+	host := config.Cfg.Options.BaseURL
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return normalizeDomain(host)
+}
+
+func normalizeDomain(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
@@ -0,0 +1,111 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"url/internal/errors"
+	"url/pkg/validators"
+)
+
+// errorPageCSP keeps the custom error pages from running scripts on the domain of the short links.
+const errorPageCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src * data:; font-src *"
+
+// Fallback is where the visitors of a link which cannot be followed are sent.
+// The URL takes precedence over the template, which is an html/template page.
+type Fallback struct {
+	URL      string `db:"url" json:"url"`
+	Template string `db:"template" json:"template"`
+}
+
+type FallbackDTO struct {
+	URL      string `json:"url" validate:"omitempty,url"`
+	Template string `json:"template" validate:"max=65536"`
+}
+
+// LoadErrorPage parses the error page template at the given path.
+// Without a path the default error page is returned.
+func LoadErrorPage(path string) (*template.Template, error) {
+	if path == "" {
+		return defaultErrorPage, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New("error").Parse(string(b))
+}
+
+func (s service) GetFallback(ctx context.Context, userID int) (Fallback, error) {
+	fallback, err := s.store.FindUserFallback(nil, userID)
+	if err == sql.ErrNoRows {
+		return Fallback{}, nil
+	}
+	return fallback, err
+}
+
+func (s service) SetFallback(ctx context.Context, req FallbackDTO, userID int) (Fallback, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Fallback{}, err
+	}
+	if req.URL != "" {
+		// visitors of every dead link of the user are sent to the fallback, like to a destination
+		if v := s.checkDestination(ctx, req.URL); v != nil {
+			return Fallback{}, errors.InvalidFields([]errors.FieldError{{Field: "url", Rule: v.Rule, Error: v.Message}})
+		}
+	}
+	if req.Template != "" {
+		if _, err := template.New("error").Parse(req.Template); err != nil {
+			return Fallback{}, errors.BadRequest(err.Error())
+		}
+	}
+	fallback := Fallback{URL: req.URL, Template: req.Template}
+	if err := s.store.SaveUserFallback(nil, userID, fallback); err != nil {
+		return Fallback{}, err
+	}
+	return fallback, nil
+}
+
+// CodeFallback returns the fallback of the owner of the code.
+// Unknown codes have no owner, so their fallback is empty.
+func (s service) CodeFallback(ctx context.Context, code string) (Fallback, error) {
+	fallback, err := s.store.FindCodeFallback(nil, code)
+	if err == sql.ErrNoRows {
+		return Fallback{}, nil
+	}
+	return fallback, err
+}
+
+// wantsHTML returns true if the client prefers html over json, like browsers do.
+// Clients accepting anything, like most API clients, get json.
+func wantsHTML(r *http.Request) bool {
+	htmlQ, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			if q > htmlQ {
+				htmlQ = q
+			}
+		case "application/json":
+			if q > jsonQ {
+				jsonQ = q
+			}
+		}
+	}
+	return htmlQ > jsonQ
+}
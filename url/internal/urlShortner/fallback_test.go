@@ -0,0 +1,37 @@
+package urlShortner
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWantsHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", true},
+		{"xhtml", "application/xhtml+xml", true},
+		{"json", "application/json", false},
+		{"no header", "", false},
+		{"anything", "*/*", false},
+		{"html preferred", "application/json;q=0.5, text/html", true},
+		{"json preferred", "text/html;q=0.5, application/json", false},
+		{"same quality", "text/html, application/json", false},
+		{"uppercase", "TEXT/HTML", true},
+		{"invalid quality is skipped", "text/html;q=high, application/json;q=0.1", false},
+		{"invalid part is skipped", "text/html, ;;", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/abc", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := wantsHTML(r); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package urlShortner
+
+import (
+	"github.com/lib/pq"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Link is a short link owned by a user.
+type Link struct {
+	ID           int            `db:"link_id" json:"id"`
+	URL          string         `db:"url" json:"url"`
+	CanonicalURL string         `db:"canonical_url" json:"canonical_url"`
+	Code         string         `db:"shortner_path" json:"code"`
+	Domain       string         `db:"domain" json:"domain,omitempty"`
+	ShortURL     string         `db:"-" json:"short_url"`
+	ExpiresAt    *time.Time     `db:"expires_at" json:"expires_at,omitempty"`
+	MaxClicks    *int64         `db:"max_clicks" json:"max_clicks,omitempty"`
+	Password     *string        `db:"password" json:"-"`
+	Status       *int           `db:"redirect_status" json:"redirect_status,omitempty"`
+	Targets      TargetRules    `db:"targets" json:"targets,omitempty"`
+	Variants     Variants       `db:"variants" json:"variants,omitempty"`
+	Tags         pq.StringArray `db:"tags" json:"tags"`
+	FolderID     *int           `db:"folder_id" json:"folder_id,omitempty"`
+	ActiveFrom   *time.Time     `db:"active_from" json:"active_from,omitempty"`
+	ActiveUntil  *time.Time     `db:"active_until" json:"active_until,omitempty"`
+	InactiveURL  *string        `db:"inactive_url" json:"inactive_url,omitempty"`
+	Title        *string        `db:"title" json:"title,omitempty"`
+	Description  *string        `db:"description" json:"description,omitempty"`
+	Interstitial bool           `db:"interstitial" json:"interstitial"`
+	State        string         `db:"state" json:"state"`
+	BanReason    *string        `db:"ban_reason" json:"ban_reason,omitempty"`
+	OG           OpenGraph      `db:"og" json:"og"`
+	FetchedOG    OpenGraph      `db:"og_fetched" json:"og_fetched"`
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt    *time.Time     `db:"deleted_at" json:"deleted_at,omitempty"`
+	// DeletedClicks is the click counter of the link when it was deleted.
+	DeletedClicks *int64 `db:"deleted_clicks" json:"-"`
+	UTM
+}
+
+// UTM are the campaign parameters added to the destination of a link.
+type UTM struct {
+	Source   string `db:"utm_source" json:"utm_source,omitempty" validate:"max=100"`
+	Medium   string `db:"utm_medium" json:"utm_medium,omitempty" validate:"max=100"`
+	Campaign string `db:"utm_campaign" json:"utm_campaign,omitempty" validate:"max=100"`
+	Term     string `db:"utm_term" json:"utm_term,omitempty" validate:"max=100"`
+	Content  string `db:"utm_content" json:"utm_content,omitempty" validate:"max=100"`
+}
+
+// IsEmpty returns true if no parameter is set.
+func (utm UTM) IsEmpty() bool {
+	return utm == UTM{}
+}
+
+// AddTo sets the parameters on the query of the url, replacing the ones it already has.
+// The other query parameters of the url are kept as they are, in their order and encoding.
+func (utm UTM) AddTo(u *url.URL) {
+	if utm.IsEmpty() {
+		return
+	}
+	params := []struct{ name, value string }{
+		{"utm_source", utm.Source},
+		{"utm_medium", utm.Medium},
+		{"utm_campaign", utm.Campaign},
+		{"utm_term", utm.Term},
+		{"utm_content", utm.Content},
+	}
+	set := make(map[string]bool, len(params))
+	for _, param := range params {
+		if param.value != "" {
+			set[param.name] = true
+		}
+	}
+	var pairs []string
+	if u.RawQuery != "" {
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			name := pair
+			if i := strings.Index(pair, "="); i >= 0 {
+				name = pair[:i]
+			}
+			if unescaped, err := url.QueryUnescape(name); err == nil && set[unescaped] {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	for _, param := range params {
+		if param.value != "" {
+			pairs = append(pairs, param.name+"="+url.QueryEscape(param.value))
+		}
+	}
+	u.RawQuery = strings.Join(pairs, "&")
+}
+
+// LinkClicks is a link with the total clicks of it.
+type LinkClicks struct {
+	Link
+	Clicks int `db:"clicks" json:"clicks"`
+}
+
+// LinkChange is a change of the destination of a link.
+type LinkChange struct {
+	ID          int       `db:"change_id" json:"id"`
+	PreviousURL string    `db:"previous_url" json:"previous_url"`
+	URL         string    `db:"url" json:"url"`
+	ChangedBy   string    `db:"changed_by" json:"changed_by"`
+	ChangedAt   time.Time `db:"changed_at" json:"changed_at"`
+}
+
+// Destination is where a visitor of a link is redirected to.
+// Visitors of interstitial links see the preview before being redirected.
+type Destination struct {
+	URL          string
+	Status       int
+	Interstitial *Preview
+}
+
+// Tag labels links of a user, a link can have many tags.
+type Tag struct {
+	ID    int    `db:"tag_id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Links int    `db:"links" json:"links"`
+}
+
+// Folder groups links of a user, a link is in at most one folder.
+type Folder struct {
+	ID    int    `db:"folder_id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Links int    `db:"links" json:"links"`
+}
+
+// Domain is a branded domain of a user, it serves the links of the user once it is verified.
+type Domain struct {
+	ID         int          `db:"domain_id" json:"id"`
+	Name       string       `db:"name" json:"name"`
+	Token      string       `db:"token" json:"-"`
+	Record     DomainRecord `db:"-" json:"record"`
+	VerifiedAt *time.Time   `db:"verified_at" json:"verified_at"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+}
+
+// DomainRecord is the TXT record which proves the user controls the domain.
+type DomainRecord struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LinkReport is an abuse report of a link by a visitor.
+type LinkReport struct {
+	ID          int        `db:"report_id" json:"id"`
+	LinkID      int        `db:"link_id" json:"-"`
+	Code        string     `db:"shortner_path" json:"code"`
+	ShortURL    string     `db:"-" json:"short_url"`
+	URL         string     `db:"url" json:"url"`
+	State       string     `db:"state" json:"state"`
+	Reason      string     `db:"reason" json:"reason"`
+	Details     string     `db:"details" json:"details"`
+	Fingerprint string     `db:"fingerprint" json:"fingerprint"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	ReviewedAt  *time.Time `db:"reviewed_at" json:"reviewed_at"`
+}
+
+// ReportPage is a single page of the abuse reports.
+type ReportPage struct {
+	Items   []LinkReport `json:"items"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+	Total   int          `json:"total"`
+}
+
+// LinkFilter is used to filter and paginate the links of a user.
+type LinkFilter struct {
+	Search   string
+	From     time.Time
+	To       time.Time
+	Tag      string
+	FolderID int
+	// Deleted selects the links in the trash instead of the other links.
+	Deleted bool
+	Offset  int
+	Limit   int
+}
+
+// LinkPage is a single page of the links of a user.
+type LinkPage struct {
+	Items   []Link `json:"items"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	Total   int    `json:"total"`
+}
@@ -0,0 +1,36 @@
+package urlShortner
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestUTMAddTo(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		utm  UTM
+		want string
+	}{
+		{"no parameters", "https://example.com/a?b=1", UTM{}, "https://example.com/a?b=1"},
+		{"without query", "https://example.com/a", UTM{Source: "news", Medium: "email"}, "https://example.com/a?utm_source=news&utm_medium=email"},
+		{"after the query", "https://example.com/?z=1&a=2", UTM{Campaign: "spring sale"}, "https://example.com/?z=1&a=2&utm_campaign=spring+sale"},
+		{"replaces the same parameter", "https://example.com/?utm_source=old&x=1", UTM{Source: "new"}, "https://example.com/?x=1&utm_source=new"},
+		{"keeps the other utm parameters", "https://example.com/?utm_medium=social", UTM{Source: "new"}, "https://example.com/?utm_medium=social&utm_source=new"},
+		{"keeps repeated keys in order", "https://example.com/?b=2&a=1&b=1", UTM{Term: "t"}, "https://example.com/?b=2&a=1&b=1&utm_term=t"},
+		{"keeps the encoding", "https://example.com/?sig=a%2Fb~c&path=/x,y", UTM{Content: "c"}, "https://example.com/?sig=a%2Fb~c&path=/x,y&utm_content=c"},
+		{"keeps the fragment", "https://example.com/#top", UTM{Source: "s"}, "https://example.com/?utm_source=s#top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.utm.AddTo(u)
+			if got := u.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"url/pkg/policy"
+	"url/pkg/validators"
+)
+
+const (
+	// metadataTimeout limits fetching the metadata of a destination.
+	metadataTimeout = 5 * time.Second
+
+	// maxMetadataRedirects limits the redirects followed fetching the metadata of a destination.
+	maxMetadataRedirects = 5
+
+	// maxMetadataPageSize is how much of the destination page is read looking for its metadata.
+	maxMetadataPageSize = 512 * 1024
+
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+)
+
+// crawlerUserAgents are the user agents of the apps unfurling shared links, they get the OpenGraph page instead of the redirect.
+var crawlerUserAgents = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"slackbot",
+	"linkedinbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"pinterest",
+	"redditbot",
+	"embedly",
+	"vkshare",
+	"mastodon",
+}
+
+// HTTPClient sends the requests fetching the metadata of destinations.
+// It is satisfied by *http.Client and can be replaced by a stub.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// newMetadataClient creates the default client fetching the metadata of destinations.
+// It never connects to addresses of private networks, so destinations can not make the server request its own network,
+// and every redirect has to pass the policy like the destination itself.
+func newMetadataClient(destinations *policy.Policy) *http.Client {
+	dialer := &net.Dialer{Timeout: metadataTimeout, Control: policy.Control}
+	return &http.Client{
+		Timeout: metadataTimeout,
+		Transport: &http.Transport{
+			// no proxy, the dialer has to see the addresses of the destinations
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: metadataTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxMetadataRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxMetadataRedirects)
+			}
+			if v := destinations.Check(req.Context(), req.URL.String()); v != nil {
+				return v
+			}
+			return nil
+		},
+	}
+}
+
+// OpenGraph is the metadata shown when a link is shared.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty" validate:"max=200"`
+	Description string `json:"description,omitempty" validate:"max=500"`
+	Image       string `json:"image,omitempty" validate:"omitempty,url"`
+}
+
+// IsEmpty returns true if no metadata is set.
+func (og OpenGraph) IsEmpty() bool {
+	return og == OpenGraph{}
+}
+
+// Or returns the metadata with the missing values taken from the fallback.
+func (og OpenGraph) Or(fallback OpenGraph) OpenGraph {
+	if og.Title == "" {
+		og.Title = fallback.Title
+	}
+	if og.Description == "" {
+		og.Description = fallback.Description
+	}
+	if og.Image == "" {
+		og.Image = fallback.Image
+	}
+	return og
+}
+
+// RedisArg implements the redis.Argument interface.
+func (og OpenGraph) RedisArg() interface{} {
+	return jsonArg(og)
+}
+
+// RedisScan implements the redis.Scanner interface.
+func (og *OpenGraph) RedisScan(src interface{}) error {
+	return scanJSON(src, og)
+}
+
+// Value implements the driver.Valuer interface.
+func (og OpenGraph) Value() (driver.Value, error) {
+	if og.IsEmpty() {
+		return nil, nil
+	}
+	return json.Marshal(og)
+}
+
+// Scan implements the sql.Scanner interface.
+func (og *OpenGraph) Scan(src interface{}) error {
+	return scanJSON(src, og)
+}
+
+// isCrawler returns true if the user agent belongs to an app unfurling links.
+func isCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, crawler := range crawlerUserAgents {
+		if strings.Contains(userAgent, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOG overrides the metadata fetched from the destination of the link.
+func (s service) SetOG(ctx context.Context, code string, og OpenGraph, userID int) (Link, error) {
+	if ok, err := validators.Validate(og); !ok {
+		return Link{}, err
+	}
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	title := ""
+	if link.Title != nil {
+		title = *link.Title
+	}
+	tx := s.store.NewTx()
+	if err := s.store.UpdateLinkOG(tx, link.ID, og); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	if err := s.repo.SetMetadata(ctx, link.Code, title, og.Or(link.FetchedOG)); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	s.store.Commit(tx)
+	return s.Get(ctx, code, userID)
+}
+
+// fetchMetadata looks up the metadata of the destinations in the background, one after the other.
+// The destinations of password protected links are not fetched, so their metadata does not give them away.
+func (s service) fetchMetadata(links []Link) {
+	go func() {
+		for _, link := range links {
+			if link.Password == nil {
+				s.fetchLinkMetadata(link)
+			}
+		}
+	}()
+}
+
+// fetchLinkMetadata saves the title and the OpenGraph metadata of the destination page with the link.
+// The metadata set by the owner takes precedence over the fetched one.
+func (s service) fetchLinkMetadata(link Link) {
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+	// the policy may have changed since the link was created
+	if v := s.checkDestination(ctx, link.URL); v != nil {
+		s.logger.With(ctx).Infof("skipped fetching the metadata of %s: %s", link.URL, v)
+		return
+	}
+	title, fetched, err := pageMetadata(ctx, s.client, link.URL)
+	if err != nil {
+		s.logger.With(ctx).Infof("failed fetching the metadata of %s: %s", link.URL, err)
+		return
+	}
+	if title == "" && fetched.IsEmpty() {
+		return
+	}
+	if err := s.store.UpdateLinkMetadata(nil, link.Code, title, fetched); err != nil {
+		s.logger.With(ctx).Errorf("failed saving the metadata of %s: %s", link.Code, err)
+		return
+	}
+	if err := s.repo.SetMetadata(ctx, link.Code, title, link.OG.Or(fetched)); err != nil {
+		s.logger.With(ctx).Errorf("failed saving the metadata of %s: %s", link.Code, err)
+	}
+}
+
+// pageMetadata fetches the html page and returns its title and OpenGraph metadata.
+// Missing OpenGraph values are taken from the title and the description of the page.
+func pageMetadata(ctx context.Context, client HTTPClient, url string) (string, OpenGraph, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", OpenGraph{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	res, err := client.Do(req)
+	if err != nil {
+		return "", OpenGraph{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(res.Header.Get("Content-Type"), "html") {
+		return "", OpenGraph{}, nil
+	}
+
+	var title string
+	var og, fallback OpenGraph
+	tokenizer := html.NewTokenizer(io.LimitReader(res.Body, maxMetadataPageSize))
+	for done := false; !done; {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() != io.EOF {
+				return "", OpenGraph{}, tokenizer.Err()
+			}
+			done = true
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				if title == "" && tokenizer.Next() == html.TextToken {
+					title = shorten(string(tokenizer.Text()), maxTitleLength)
+				}
+			case "meta":
+				readMeta(token, &og, &fallback)
+			case "body":
+				// the metadata is in the head
+				done = true
+			}
+		}
+	}
+	fallback.Title = title
+	return title, og.Or(fallback), nil
+}
+
+// readMeta reads the OpenGraph and the twitter card meta tags into og and the description into fallback.
+func readMeta(token html.Token, og, fallback *OpenGraph) {
+	var name, content string
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "property", "name":
+			name = strings.ToLower(attr.Val)
+		case "content":
+			content = attr.Val
+		}
+	}
+	switch name {
+	case "og:title", "twitter:title":
+		if og.Title == "" {
+			og.Title = shorten(content, maxTitleLength)
+		}
+	case "og:description", "twitter:description":
+		if og.Description == "" {
+			og.Description = shorten(content, maxDescriptionLength)
+		}
+	case "og:image", "og:image:url", "twitter:image":
+		if og.Image == "" && (strings.HasPrefix(content, "https://") || strings.HasPrefix(content, "http://")) {
+			og.Image = content
+		}
+	case "description":
+		fallback.Description = shorten(content, maxDescriptionLength)
+	}
+}
+
+// shorten collapses the whitespace of the text and cuts it to the given number of characters.
+func shorten(text string, length int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > length {
+		return string(runes[:length])
+	}
+	return text
+}
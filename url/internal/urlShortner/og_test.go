@@ -0,0 +1,164 @@
+package urlShortner
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url/pkg/policy"
+)
+
+// stubClient answers every request with the same response.
+type stubClient struct {
+	status      int
+	contentType string
+	body        string
+	err         error
+}
+
+func (c stubClient) Do(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", c.contentType)
+	return &http.Response{
+		StatusCode: c.status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(c.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestPageMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		client stubClient
+		title  string
+		og     OpenGraph
+		err    bool
+	}{
+		{
+			name: "title and og tags",
+			client: stubClient{http.StatusOK, "text/html; charset=utf-8", `<html><head>
+				<title> The   Title </title>
+				<meta property="og:title" content="OG Title">
+				<meta property="og:description" content="OG Description">
+				<meta property="og:image" content="https://example.com/image.png">
+				<meta name="description" content="Description">
+				</head><body></body></html>`, nil},
+			title: "The Title",
+			og:    OpenGraph{Title: "OG Title", Description: "OG Description", Image: "https://example.com/image.png"},
+		},
+		{
+			name: "twitter tags",
+			client: stubClient{http.StatusOK, "text/html", `<head>
+				<meta name="twitter:title" content="Card Title">
+				<meta name="twitter:image" content="http://example.com/card.png">
+				</head>`, nil},
+			og: OpenGraph{Title: "Card Title", Image: "http://example.com/card.png"},
+		},
+		{
+			name: "fallback to title and description",
+			client: stubClient{http.StatusOK, "text/html", `<head>
+				<title>Page</title>
+				<meta name="description" content="About the page">
+				</head>`, nil},
+			title: "Page",
+			og:    OpenGraph{Title: "Page", Description: "About the page"},
+		},
+		{
+			name: "relative image is ignored",
+			client: stubClient{http.StatusOK, "text/html", `<head>
+				<meta property="og:image" content="/image.png">
+				</head>`, nil},
+		},
+		{
+			name:   "tags of the body are ignored",
+			client: stubClient{http.StatusOK, "text/html", `<head></head><body><title>Body</title></body>`, nil},
+		},
+		{
+			name:   "not html",
+			client: stubClient{http.StatusOK, "application/json", `{"title": "JSON"}`, nil},
+		},
+		{
+			name:   "not ok",
+			client: stubClient{http.StatusNotFound, "text/html", `<title>Not Found</title>`, nil},
+		},
+		{
+			name: "beyond the size limit",
+			client: stubClient{http.StatusOK, "text/html",
+				`<head><!--` + strings.Repeat("a", maxMetadataPageSize) + `--><title>Late</title></head>`, nil},
+		},
+		{
+			name:   "failed request",
+			client: stubClient{err: errors.New("connection refused")},
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, og, err := pageMetadata(context.Background(), tt.client, "https://example.com")
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			if title != tt.title {
+				t.Errorf("got title %q, want %q", title, tt.title)
+			}
+			if og != tt.og {
+				t.Errorf("got og %+v, want %+v", og, tt.og)
+			}
+		})
+	}
+}
+
+func TestPageMetadataShortens(t *testing.T) {
+	long := strings.Repeat("x", maxTitleLength+10)
+	client := stubClient{http.StatusOK, "text/html", `<title>` + long + `</title>`, nil}
+	title, _, err := pageMetadata(context.Background(), client, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(title) != maxTitleLength {
+		t.Errorf("got title of %d characters, want %d", len(title), maxTitleLength)
+	}
+}
+
+func TestMetadataClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<title>Internal</title>`))
+	}))
+	defer server.Close()
+
+	// even a policy allowing private hosts does not let the client connect to them
+	client := newMetadataClient(policy.New(policy.Config{AllowPrivate: true}))
+	if _, _, err := pageMetadata(context.Background(), client, server.URL); err == nil {
+		t.Error("got no error fetching a loopback address")
+	}
+}
+
+func TestMetadataClientChecksRedirects(t *testing.T) {
+	client := newMetadataClient(policy.New(policy.Config{Blocklist: []string{"evil.example"}}))
+	tests := []struct {
+		url string
+		via int
+		err bool
+	}{
+		{"https://example.com/next", 1, false},
+		{"https://login.evil.example/", 1, true},
+		{"ftp://example.com/", 1, true},
+		{"http://169.254.169.254/latest/meta-data/", 1, true},
+		{"https://example.com/next", maxMetadataRedirects, true},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		via := make([]*http.Request, tt.via)
+		if err := client.CheckRedirect(req, via); (err != nil) != tt.err {
+			t.Errorf("redirect to %s after %d requests: got error %v, want error %v", tt.url, tt.via, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package urlShortner
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<title>Protected link</title>
+</head>
+<body>
+	<form method="post">
+		<p>This link is protected, enter the password to continue.</p>
+		{{if .Error}}<p style="color: #c00">{{.Error}}</p>{{end}}
+		<input type="password" name="password" autofocus required>
+		<button type="submit">Continue</button>
+	</form>
+</body>
+</html>
+`))
+
+// previewPage shows where a link goes, with a delay it redirects there afterwards.
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	{{if and .Delay .URL}}<meta http-equiv="refresh" content="{{.Delay}};url={{.URL}}">{{end}}
+	<title>{{if .Title}}{{.Title}}{{else}}Link preview{{end}}</title>
+</head>
+<body>
+	<p>{{.ShortURL}} goes to</p>
+	{{if .Protected}}
+	<p>a destination protected by a password.</p>
+	{{else}}
+	<p><a href="{{.URL}}" rel="noopener noreferrer">{{.URL}}</a></p>
+	{{if .Title}}<h1>{{.Title}}</h1>{{end}}
+	{{end}}
+	{{if .Description}}<p>{{.Description}}</p>{{end}}
+	{{if and .Delay .URL}}<p>You will be redirected in {{.Delay}} seconds.</p>{{end}}
+</body>
+</html>
+`))
+
+// unfurlPage is served to the apps unfurling shared links, with the OpenGraph metadata of the link.
+var unfurlPage = template.Must(template.New("unfurl").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta property="og:type" content="website">
+	<meta property="og:url" content="{{.ShortURL}}">
+	<meta property="og:title" content="{{with .OG.Title}}{{.}}{{else}}{{.ShortURL}}{{end}}">
+	{{with .OG.Description}}<meta property="og:description" content="{{.}}">{{end}}
+	{{with .OG.Image}}<meta property="og:image" content="{{.}}">
+	<meta name="twitter:card" content="summary_large_image">{{end}}
+	<title>{{with .OG.Title}}{{.}}{{else}}{{.ShortURL}}{{end}}</title>
+</head>
+<body>
+	{{if .URL}}<a href="{{.URL}}">{{.URL}}</a>{{end}}
+</body>
+</html>
+`))
+
+type previewPageData struct {
+	Preview
+	Delay int
+}
+
+// defaultErrorPage is shown to browsers visiting a link which cannot be followed, unless another page is configured.
+var defaultErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<title>Link not available</title>
+</head>
+<body>
+	<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// errorPageData is passed to the error pages, including the custom ones of the server and the users.
+type errorPageData struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+// bannedPage warns the visitors of a link banned for abuse, it is shown instead of the fallback of the owner.
+var bannedPage = template.Must(template.New("banned").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<meta name="robots" content="noindex">
+	<title>Warning: link disabled</title>
+</head>
+<body>
+	<h1>Warning</h1>
+	<p>The link {{.Code}} has been disabled because it was reported for abuse, for example phishing or malware.</p>
+	<p>Do not enter passwords or personal information on a page you were sent to by this link.</p>
+</body>
+</html>
+`))
+
+type passwordPageData struct {
+	Error string
+}
+
+// renderPage writes the given template as an html response.
+func renderPage(w http.ResponseWriter, status int, page *template.Template, data interface{}) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	return page.Execute(w, data)
+}
@@ -0,0 +1,56 @@
+package urlShortner
+
+import (
+	"context"
+)
+
+const (
+	// previewSuffix is appended to a code to see where the link goes instead of following it.
+	previewSuffix = "+"
+
+	// interstitialDelay is how many seconds visitors of an interstitial link see the preview before being redirected.
+	interstitialDelay = 5
+)
+
+// Preview describes where a link goes, without following it.
+// The destination of password protected links is not shown.
+type Preview struct {
+	Code        string    `json:"code"`
+	ShortURL    string    `json:"short_url"`
+	URL         string    `json:"url,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	OG          OpenGraph `json:"og"`
+	Protected   bool      `json:"protected"`
+}
+
+// Preview returns where the link goes, the visit is not tracked.
+func (s service) Preview(ctx context.Context, code string) (Preview, error) {
+	item, err := s.repo.FindOne(ctx, code)
+	if err != nil {
+		return Preview{}, err
+	}
+	if err := checkState(item); err != nil {
+		return Preview{}, err
+	}
+	if err := checkExpired(item); err != nil {
+		return Preview{}, err
+	}
+	if err := checkClicks(item); err != nil {
+		return Preview{}, err
+	}
+	if _, err := checkActive(item); err != nil {
+		return Preview{}, err
+	}
+	return newPreview(code, item), nil
+}
+
+func newPreview(code string, item Item) Preview {
+	preview := Preview{Code: code, ShortURL: shortURL(code), Description: item.Description, OG: item.OG}
+	if item.Password != "" {
+		preview.Protected = true
+		return preview
+	}
+	preview.URL, preview.Title = item.URL, item.Title
+	return preview
+}
@@ -0,0 +1,40 @@
+package urlShortner
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"url/internal/errors"
+)
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   Item
+		status int
+	}{
+		{"link", Item{URL: "https://example.com"}, 0},
+		{"clicks left", Item{URL: "https://example.com", MaxClicks: 3, Clicks: 2}, 0},
+		{"maximum clicks reached", Item{URL: "https://example.com", MaxClicks: 3, Clicks: 3}, http.StatusGone},
+		{"disabled link", Item{URL: "https://example.com", State: LinkDisabled}, http.StatusGone},
+		{"banned link", Item{URL: "https://example.com", State: LinkBanned}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := service{repo: newStubRepository(map[string]Item{"abc": tt.item})}
+			preview, err := s.Preview(context.Background(), "abc")
+			if tt.status != 0 {
+				if e, ok := err.(errors.ErrorResponse); !ok || e.Status != tt.status {
+					t.Fatalf("got error %v, want status %d", err, tt.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if preview.URL != tt.item.URL {
+				t.Errorf("got url %s, want %s", preview.URL, tt.item.URL)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package urlShortner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"url/internal/errors"
+	"url/pkg/qr"
+)
+
+const (
+	// sourceParam is the query parameter of the short url telling where the visitor comes from.
+	sourceParam = "src"
+
+	// SourceQR marks the visitors who scanned the QR code of a link.
+	SourceQR = "qr"
+
+	defaultQRSize   = 256
+	minQRSize       = 64
+	maxQRSize       = 2048
+	defaultQRMargin = 4
+	maxQRMargin     = 16
+)
+
+// QRImage is a rendered QR code.
+type QRImage struct {
+	ContentType string
+	Data        []byte
+}
+
+type qrQueries struct {
+	Format     string
+	Size       string
+	Margin     string
+	Level      string
+	Foreground string
+	Background string
+}
+
+// QR renders the short url of the link as a QR code, scans of the code are tracked with the qr source.
+func (s service) QR(ctx context.Context, code string, queries qrQueries, userID int) (QRImage, error) {
+	options, err := qrQueriesValidator(queries)
+	if err != nil {
+		return QRImage{}, errors.BadRequest(err.Error())
+	}
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return QRImage{}, err
+	}
+	qrCode, err := qr.New(qrURL(link.Code), options)
+	if err != nil {
+		return QRImage{}, err
+	}
+	if options.Size < qrCode.Modules() {
+		return QRImage{}, errors.BadRequest(fmt.Sprintf("the size must be at least %d pixels for this code", qrCode.Modules()))
+	}
+	if queries.Format == "svg" {
+		return QRImage{ContentType: "image/svg+xml", Data: qrCode.SVG()}, nil
+	}
+	data, err := qrCode.PNG()
+	if err != nil {
+		return QRImage{}, err
+	}
+	return QRImage{ContentType: "image/png", Data: data}, nil
+}
+
+// qrURL returns the short url of the code, marked as coming from a QR code.
+func qrURL(code string) string {
+	return shortURL(code) + "?" + url.Values{sourceParam: {SourceQR}}.Encode()
+}
+
+func qrQueriesValidator(queries qrQueries) (qr.Options, error) {
+	if queries.Format != "png" && queries.Format != "svg" {
+		return qr.Options{}, fmt.Errorf("enter the correct format, %s is not png or svg", queries.Format)
+	}
+	size, err := strconv.Atoi(queries.Size)
+	if err != nil || size < minQRSize || size > maxQRSize {
+		return qr.Options{}, fmt.Errorf("enter the correct size, %s is not between %d and %d", queries.Size, minQRSize, maxQRSize)
+	}
+	margin, err := strconv.Atoi(queries.Margin)
+	if err != nil || margin < 0 || margin > maxQRMargin {
+		return qr.Options{}, fmt.Errorf("enter the correct margin, %s is not between 0 and %d", queries.Margin, maxQRMargin)
+	}
+	if _, ok := qr.Levels[queries.Level]; !ok {
+		return qr.Options{}, fmt.Errorf("enter the correct level, %s is not one of L, M, Q and H", queries.Level)
+	}
+	foreground, err := qr.ParseHex(queries.Foreground)
+	if err != nil {
+		return qr.Options{}, fmt.Errorf("enter the correct fg color, %s", err)
+	}
+	background, err := qr.ParseHex(queries.Background)
+	if err != nil {
+		return qr.Options{}, fmt.Errorf("enter the correct bg color, %s", err)
+	}
+	return qr.Options{
+		Size:       size,
+		Margin:     margin,
+		Level:      queries.Level,
+		Foreground: foreground,
+		Background: background,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package urlShortner
+
+import "testing"
+
+func TestQRQueriesValidator(t *testing.T) {
+	valid := qrQueries{Format: "png", Size: "256", Margin: "4", Level: "M", Foreground: "000000", Background: "#fff"}
+	tests := []struct {
+		name   string
+		modify func(q *qrQueries)
+		err    bool
+	}{
+		{"defaults", func(q *qrQueries) {}, false},
+		{"svg", func(q *qrQueries) { q.Format = "svg" }, false},
+		{"unknown format", func(q *qrQueries) { q.Format = "gif" }, true},
+		{"smallest size", func(q *qrQueries) { q.Size = "64" }, false},
+		{"largest size", func(q *qrQueries) { q.Size = "2048" }, false},
+		{"too small", func(q *qrQueries) { q.Size = "63" }, true},
+		{"too large", func(q *qrQueries) { q.Size = "2049" }, true},
+		{"size is not a number", func(q *qrQueries) { q.Size = "big" }, true},
+		{"no margin", func(q *qrQueries) { q.Margin = "0" }, false},
+		{"negative margin", func(q *qrQueries) { q.Margin = "-1" }, true},
+		{"too large margin", func(q *qrQueries) { q.Margin = "17" }, true},
+		{"unknown level", func(q *qrQueries) { q.Level = "X" }, true},
+		{"invalid color", func(q *qrQueries) { q.Foreground = "black" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := valid
+			tt.modify(&queries)
+			if _, err := qrQueriesValidator(queries); (err != nil) != tt.err {
+				t.Errorf("got error %v, want error %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"url/internal/errors"
+	"url/internal/track"
+	"url/pkg/validators"
+)
+
+const (
+	// LinkActive links redirect their visitors.
+	LinkActive = "active"
+
+	// LinkDisabled links are stopped by their owner, who can enable them again.
+	LinkDisabled = "disabled"
+
+	// LinkBanned links are stopped by an admin, their visitors see a warning instead.
+	LinkBanned = "banned"
+)
+
+var (
+	// ErrDisabled is returned for the links which are disabled by their owner.
+	ErrDisabled = errors.Gone("the link has been disabled by its owner")
+
+	// ErrBanned is returned for the links which are banned by an admin, visitors see the warning page.
+	ErrBanned = errors.Forbidden("the link has been banned for abuse")
+)
+
+type ReportDTO struct {
+	// URL is the short url which is reported.
+	URL     string `json:"url" validate:"required,url"`
+	Reason  string `json:"reason" validate:"required,oneof=phishing malware spam illegal other"`
+	Details string `json:"details" validate:"max=1000"`
+}
+
+type BanDTO struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+type reportQueries struct {
+	Page     string
+	PerPage  string
+	Reviewed string
+}
+
+// Report records the abuse report of a visitor, repeated reports of the same visitor on the same day are ignored.
+func (s service) Report(r *http.Request, req ReportDTO) error {
+	if ok, err := validators.Validate(req); !ok {
+		return err
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	host := normalizeDomain(u.Hostname())
+	if host == "" {
+		host = serverHost()
+	}
+	// unlike visitors, reports of other hosts can not be answered by the links of the server
+	if host != serverHost() {
+		branded, err := s.repo.HasDomain(r.Context(), host)
+		if err != nil {
+			return err
+		} else if !branded {
+			return errors.NotFound("the url is not a short url of this server")
+		}
+	}
+	code, err := s.scope(r.Context(), host, strings.TrimSuffix(strings.Trim(u.Path, "/"), previewSuffix))
+	if err != nil {
+		return err
+	}
+	link, err := s.store.FindLink(nil, code)
+	if err == sql.ErrNoRows {
+		return errors.NotFound("")
+	} else if err != nil {
+		return err
+	}
+	return s.store.CreateReport(nil, LinkReport{
+		LinkID:      link.ID,
+		Reason:      req.Reason,
+		Details:     req.Details,
+		Fingerprint: track.VisitorKey(r, trackerSalt),
+	})
+}
+
+// ListReports returns the abuse reports to review, the newest first.
+func (s service) ListReports(ctx context.Context, queries reportQueries) (ReportPage, error) {
+	page, err := strconv.Atoi(queries.Page)
+	if err != nil || page < 1 {
+		return ReportPage{}, errors.BadRequest(fmt.Sprintf("enter the correct page, %s is not a positive number", queries.Page))
+	}
+	perPage, err := strconv.Atoi(queries.PerPage)
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return ReportPage{}, errors.BadRequest(fmt.Sprintf("enter the correct per_page, %s is not between 1 and %d", queries.PerPage, maxPerPage))
+	}
+	reviewed, err := strconv.ParseBool(queries.Reviewed)
+	if err != nil {
+		return ReportPage{}, errors.BadRequest(fmt.Sprintf("enter the correct reviewed, %s is not a boolean", queries.Reviewed))
+	}
+	reports, total, err := s.store.FindReports(nil, reviewed, (page-1)*perPage, perPage)
+	if err != nil {
+		return ReportPage{}, err
+	}
+	for i := range reports {
+		reports[i].ShortURL = shortURL(reports[i].Code)
+	}
+	return ReportPage{Items: reports, Page: page, PerPage: perPage, Total: total}, nil
+}
+
+// DismissReport marks the report as reviewed without acting on the link.
+func (s service) DismissReport(ctx context.Context, reportID int) error {
+	err := s.store.ReviewReport(nil, reportID)
+	if err == sql.ErrNoRows {
+		return errors.NotFound("")
+	}
+	return err
+}
+
+// Ban stops the link of any user and marks its reports as reviewed.
+func (s service) Ban(ctx context.Context, code string, req BanDTO) (Link, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Link{}, err
+	}
+	link, err := s.findLink(code)
+	if err != nil {
+		return Link{}, err
+	}
+	tx := s.store.NewTx()
+	if err := s.store.SetLinkState(tx, link.ID, LinkBanned, &req.Reason); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	if err := s.store.ReviewLinkReports(tx, link.ID); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	if err := s.repo.SetState(ctx, link.Code, LinkBanned); err != nil && err != errItemNotFound {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	s.store.Commit(tx)
+	return s.findLink(code)
+}
+
+// Unban lets the banned link redirect again.
+func (s service) Unban(ctx context.Context, code string) (Link, error) {
+	link, err := s.findLink(code)
+	if err != nil {
+		return Link{}, err
+	}
+	if link.State != LinkBanned {
+		return Link{}, errors.Conflict("the link is not banned")
+	}
+	if err := s.setState(ctx, link, LinkActive); err != nil {
+		return Link{}, err
+	}
+	return s.findLink(code)
+}
+
+// Disable stops the link of the user until it is enabled again.
+func (s service) Disable(ctx context.Context, code string, userID int) (Link, error) {
+	return s.changeState(ctx, code, LinkDisabled, userID)
+}
+
+func (s service) Enable(ctx context.Context, code string, userID int) (Link, error) {
+	return s.changeState(ctx, code, LinkActive, userID)
+}
+
+func (s service) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	return s.store.IsAdmin(nil, userID)
+}
+
+// changeState changes the state of the link of the user, banned links can only be changed by admins.
+func (s service) changeState(ctx context.Context, code, state string, userID int) (Link, error) {
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	if link.State == LinkBanned {
+		return Link{}, ErrBanned
+	}
+	if link.State == state {
+		return link, nil
+	}
+	if err := s.setState(ctx, link, state); err != nil {
+		return Link{}, err
+	}
+	return s.Get(ctx, code, userID)
+}
+
+// setState saves the state of the link in both stores, redis is updated last.
+// Redis drops expired links a while after their expiration, their state is only kept in postgres then.
+func (s service) setState(ctx context.Context, link Link, state string) error {
+	tx := s.store.NewTx()
+	if err := s.store.SetLinkState(tx, link.ID, state, nil); err != nil {
+		s.store.Rollback(tx)
+		return err
+	}
+	if err := s.repo.SetState(ctx, link.Code, state); err != nil && err != errItemNotFound {
+		s.store.Rollback(tx)
+		return err
+	}
+	s.store.Commit(tx)
+	return nil
+}
+
+// findLink returns the link of any user by its code.
+func (s service) findLink(code string) (Link, error) {
+	link, err := s.store.FindLink(nil, code)
+	if err == sql.ErrNoRows {
+		return Link{}, errors.NotFound("")
+	} else if err != nil {
+		return Link{}, err
+	}
+	link.ShortURL = shortURL(link.Code)
+	return link, nil
+}
+
+// checkState returns an error if the link is disabled or banned.
+func checkState(item Item) error {
+	switch item.State {
+	case LinkDisabled:
+		return ErrDisabled
+	case LinkBanned:
+		return ErrBanned
+	}
+	return nil
+}
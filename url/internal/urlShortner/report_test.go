@@ -0,0 +1,61 @@
+package urlShortner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url/internal/errors"
+)
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		linkID int
+		status int
+	}{
+		{"server link", "https://sho.rt/abc", 1, 0},
+		{"preview of a server link", "https://sho.rt/abc+", 1, 0},
+		{"branded domain link", "https://go.brand.example/abc", 2, 0},
+		{"unknown host", "https://other.example/abc", 0, http.StatusNotFound},
+		{"unknown code", "https://sho.rt/xyz", 0, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStubStore(Link{ID: 1, Code: "abc"}, Link{ID: 2, Code: scopeCode("abc", "go.brand.example")})
+			repo := newStubRepository(nil)
+			repo.domains["go.brand.example"] = true
+			s := service{store: store, repo: repo}
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/reports", nil)
+			err := s.Report(r, ReportDTO{URL: tt.url, Reason: "spam"})
+			if tt.status != 0 {
+				if e, ok := err.(errors.ErrorResponse); !ok || e.Status != tt.status {
+					t.Fatalf("got error %v, want status %d", err, tt.status)
+				}
+				if len(store.reports) != 0 {
+					t.Errorf("got %d reports, want none", len(store.reports))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(store.reports) != 1 || store.reports[0].LinkID != tt.linkID {
+				t.Errorf("got reports %+v, want one of link %d", store.reports, tt.linkID)
+			}
+		})
+	}
+}
+
+func TestBanLinkDroppedByRedis(t *testing.T) {
+	store := newStubStore(Link{ID: 1, Code: "abc"})
+	s := service{store: store, repo: newStubRepository(nil)}
+	link, err := s.Ban(context.Background(), "abc", BanDTO{Reason: "phishing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.State != LinkBanned {
+		t.Errorf("got state %s, want %s", link.State, LinkBanned)
+	}
+}
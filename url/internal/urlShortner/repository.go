@@ -6,6 +6,7 @@ import (
 	redisClient "github.com/gomodule/redigo/redis"
 	"math/rand"
 	"strconv"
+	"time"
 	"url/pkg/base62"
 	"url/pkg/log"
 	"url/pkg/redis"
@@ -14,8 +15,19 @@ import (
 
 // Repository encapsulates the logic to access from the data source.
 type Repository interface {
-	Create(ctx context.Context, URI string, similarTo string) (string, error)
-	FindOne(ctx context.Context, code string) (string, error)
+	Create(ctx context.Context, item Item, similarTo string, domain string) (string, error)
+	Claim(ctx context.Context, item Item, alias string) (bool, error)
+	CreateBatch(ctx context.Context, batch []BatchItem) ([]string, []error)
+	Exists(ctx context.Context, code string) (bool, error)
+	FindOne(ctx context.Context, code string) (Item, error)
+	Update(ctx context.Context, code string, URI string) error
+	SetMetadata(ctx context.Context, code string, title string, og OpenGraph) error
+	Delete(ctx context.Context, code string) error
+	IncrClicks(ctx context.Context, code string) (int64, error)
+	SetState(ctx context.Context, code string, state string) error
+	AddDomain(ctx context.Context, domain string) error
+	RemoveDomain(ctx context.Context, domain string) error
+	HasDomain(ctx context.Context, domain string) (bool, error)
 }
 
 // repository persists in database
@@ -29,64 +41,393 @@ func NewRepository(redis *redis.Redis, logger log.Logger) Repository {
 	return repository{redis, logger}
 }
 
+// domainsKey is the set of the verified branded domains.
+// It is outside of the Shortener hashes, so no code can shadow it.
+const domainsKey = "ShortenerDomains"
+
+// expiredRetention is how long an expired link is kept in redis to answer with gone instead of not found.
+const expiredRetention = 30 * 24 * time.Hour
+
+// Item is the redirect data of a link which is stored in the Shortener hash.
+type Item struct {
+	URL       string      `json:"url" redis:"url"`
+	ExpiresAt int64       `json:"expires_at,omitempty" redis:"expires_at,omitempty"`
+	MaxClicks int64       `json:"max_clicks,omitempty" redis:"max_clicks,omitempty"`
+	Clicks    int64       `json:"clicks,omitempty" redis:"clicks,omitempty"`
+	Password  string      `json:"-" redis:"password,omitempty"`
+	Status    int         `json:"redirect_status,omitempty" redis:"status,omitempty"`
+	Targets   TargetRules `json:"targets,omitempty" redis:"targets,omitempty"`
+	Variants  Variants    `json:"variants,omitempty" redis:"variants,omitempty"`
+	// ActiveFrom and ActiveUntil limit when the link redirects, outside of it visitors are sent to InactiveURL.
+	ActiveFrom  int64  `json:"active_from,omitempty" redis:"active_from,omitempty"`
+	ActiveUntil int64  `json:"active_until,omitempty" redis:"active_until,omitempty"`
+	InactiveURL string `json:"inactive_url,omitempty" redis:"inactive_url,omitempty"`
+	// Title of the destination page and Description of the owner are shown on the preview page.
+	Title        string `json:"title,omitempty" redis:"title,omitempty"`
+	Description  string `json:"description,omitempty" redis:"description,omitempty"`
+	Interstitial bool   `json:"interstitial,omitempty" redis:"interstitial,omitempty"`
+	// OG is the metadata shown when the link is shared, the one of the owner merged with the fetched one.
+	OG OpenGraph `json:"og,omitempty" redis:"og,omitempty"`
+	// State is empty for active links.
+	State string `json:"state,omitempty" redis:"state,omitempty"`
+}
+
+// BatchItem is an item created in a batch under its alias, a code similar to SimilarTo or a random code.
+// Codes of items with a Domain are scoped by it.
+type BatchItem struct {
+	Item      Item
+	Alias     string
+	SimilarTo string
+	Domain    string
+}
+
+// errAliasTaken is returned for the items of a batch whose alias is already used.
+var errAliasTaken = fmt.Errorf("alias is already taken")
+
+// errItemNotFound is returned when no item is stored for the code, it was never created or redis dropped it.
+var errItemNotFound = fmt.Errorf("link not found")
+
 type RandomItem struct {
-	Id  uint64 `json:"id" redis:"id"`
-	URL string `json:"url" redis:"url"`
+	Id uint64 `json:"id" redis:"id"`
+	Item
 }
 
 type SuggestedItem struct {
-	Id  string `json:"id" redis:"id"`
-	URL string `json:"url" redis:"url"`
+	Id string `json:"id" redis:"id"`
+	Item
 }
 
-func (r repository) Create(ctx context.Context, URI, similarTo string) (string, error) {
+// Create stores the item under a code similar to similarTo or a random code and returns the code.
+// The code is scoped by the domain unless it is empty.
+func (r repository) Create(ctx context.Context, item Item, similarTo, domain string) (string, error) {
 	conn := r.redis.Pool.Get()
 	defer conn.Close()
 
 	if similarTo != "" {
-		for used := true; used; used = r.isSuggestedStrExists(similarTo) {
+		for used := true; used; used = r.isSuggestedStrExists(scopeCode(similarTo, domain)) {
 			similarTo = stringSuggestion.Suggest(similarTo, 2, 11)
 		}
-		shortLink := SuggestedItem{similarTo, URI}
-		_, err := conn.Do("HMSET", redisClient.Args{"Shortener:" + similarTo}.AddFlat(shortLink)...)
-		if err != nil {
+		code := scopeCode(similarTo, domain)
+		if err := r.save(conn, "Shortener:"+code, SuggestedItem{code, item}, item.ExpiresAt); err != nil {
+			return "", err
+		}
+		return code, nil
+	}
+	// the ids of random codes are only unique on the server domain, on other domains they are stored like aliases
+	if domain != "" {
+		var code string
+		for used := true; used; used = r.isSuggestedStrExists(code) {
+			code = scopeCode(base62.Encode(rand.Uint64()), domain)
+		}
+		if err := r.save(conn, "Shortener:"+code, SuggestedItem{code, item}, item.ExpiresAt); err != nil {
 			return "", err
 		}
-		return similarTo, nil
+		return code, nil
 	}
 	var id uint64
 	for used := true; used; used = r.isIDUsed(id) {
 		id = rand.Uint64()
 	}
-	shortLink := RandomItem{id, URI}
-	_, err := conn.Do("HMSET", redisClient.Args{"Shortener:" + strconv.FormatUint(id, 10)}.AddFlat(shortLink)...)
-	if err != nil {
+	shortLink := RandomItem{id, item}
+	if err := r.save(conn, "Shortener:"+strconv.FormatUint(id, 10), shortLink, item.ExpiresAt); err != nil {
 		return "", err
 	}
 	return base62.Encode(id), nil
 }
 
-func (r repository) FindOne(ctx context.Context, code string) (string, error) {
+// Claim stores the item exactly under the alias, it returns false if the alias is already used.
+func (r repository) Claim(ctx context.Context, item Item, alias string) (bool, error) {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	// the alias must not shadow a random code either
+	if decodedId, err := base62.Decode(alias); err == nil && r.isIDUsed(decodedId) {
+		return false, nil
+	}
+	return redisClient.Bool(claimScript.Do(conn, claimArgs("Shortener:"+alias, SuggestedItem{alias, item}, item.ExpiresAt)...))
+}
+
+// claimScript stores the item given as field value pairs after the expiration time, unless the key is already used.
+// The check and the writes are done at once, so a claim never leaves a partly stored item behind.
+var claimScript = redisClient.NewScript(1, `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+redis.call("HMSET", KEYS[1], unpack(ARGV, 2))
+if tonumber(ARGV[1]) > 0 then
+	redis.call("EXPIREAT", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// claimArgs returns the arguments of claimScript storing the item in the key.
+func claimArgs(key string, item interface{}, expiresAt int64) redisClient.Args {
+	var expireAt int64
+	if expiresAt > 0 {
+		expireAt = expiresAt + int64(expiredRetention.Seconds())
+	}
+	return redisClient.Args{key, expireAt}.AddFlat(item)
+}
+
+// CreateBatch stores the items of the batch using pipelined writes.
+// It returns the code or the error of each item.
+func (r repository) CreateBatch(ctx context.Context, batch []BatchItem) ([]string, []error) {
 	conn := r.redis.Pool.Get()
 	defer conn.Close()
 
-	urlString, err := redisClient.String(conn.Do("HGET", "Shortener:"+code, "url"))
+	codes := make([]string, len(batch))
+	keys := make([]string, len(batch))
+	shortLinks := make([]interface{}, len(batch))
+	errs := make([]error, len(batch))
+	used := make(map[string]bool, len(batch))
 
-	if err != nil {
-		return "", err
-	} else if len(urlString) == 0 {
-		decodedId, err := base62.Decode(code)
-		if err != nil {
-			return "", err
+	// choose the codes, aliases are claimed with their items in a pipeline
+	for i, b := range batch {
+		switch {
+		case b.Alias != "":
+			alias := scopeCode(b.Alias, b.Domain)
+			if decodedId, err := base62.Decode(alias); err == nil && r.isIDUsed(decodedId) {
+				errs[i] = errAliasTaken
+				continue
+			}
+			codes[i], keys[i] = alias, "Shortener:"+alias
+			shortLinks[i] = SuggestedItem{alias, b.Item}
+			errs[i] = claimScript.Send(conn, claimArgs(keys[i], shortLinks[i], b.Item.ExpiresAt)...)
+		case b.SimilarTo != "":
+			similarTo := b.SimilarTo
+			for taken := true; taken; taken = used[scopeCode(similarTo, b.Domain)] || r.isSuggestedStrExists(scopeCode(similarTo, b.Domain)) {
+				similarTo = stringSuggestion.Suggest(similarTo, 2, 11)
+			}
+			code := scopeCode(similarTo, b.Domain)
+			codes[i], keys[i] = code, "Shortener:"+code
+			shortLinks[i] = SuggestedItem{code, b.Item}
+		case b.Domain != "":
+			var code string
+			for taken := true; taken; taken = used[code] || r.isSuggestedStrExists(code) {
+				code = scopeCode(base62.Encode(rand.Uint64()), b.Domain)
+			}
+			codes[i], keys[i] = code, "Shortener:"+code
+			shortLinks[i] = SuggestedItem{code, b.Item}
+		default:
+			var id uint64
+			for taken := true; taken; taken = used[base62.Encode(id)] || r.isIDUsed(id) {
+				id = rand.Uint64()
+			}
+			codes[i], keys[i] = base62.Encode(id), "Shortener:"+strconv.FormatUint(id, 10)
+			shortLinks[i] = RandomItem{id, b.Item}
 		}
-		urlString, err = redisClient.String(conn.Do("HGET", "Shortener:"+strconv.FormatUint(decodedId, 10), "url"))
+		used[codes[i]] = true
+	}
+	if err := conn.Flush(); err != nil {
+		return codes, fillErrors(errs, err)
+	}
+	for i, b := range batch {
+		if b.Alias == "" || errs[i] != nil {
+			continue
+		}
+		claimed, err := redisClient.Bool(conn.Receive())
 		if err != nil {
-			return "", err
-		} else if len(urlString) == 0 {
-			return "", fmt.Errorf("%s not found", code)
+			errs[i] = err
+		} else if !claimed {
+			errs[i] = errAliasTaken
 		}
 	}
-	return urlString, nil
+
+	// write the other items in a single pipeline, the claimed aliases are already written
+	for i, b := range batch {
+		if errs[i] != nil || b.Alias != "" {
+			continue
+		}
+		errs[i] = conn.Send("HMSET", redisClient.Args{keys[i]}.AddFlat(shortLinks[i])...)
+		if b.Item.ExpiresAt > 0 && errs[i] == nil {
+			errs[i] = conn.Send("EXPIREAT", keys[i], b.Item.ExpiresAt+int64(expiredRetention.Seconds()))
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return codes, fillErrors(errs, err)
+	}
+	for i, b := range batch {
+		if errs[i] != nil || b.Alias != "" {
+			continue
+		}
+		if _, err := conn.Receive(); err != nil {
+			errs[i] = err
+		}
+		if b.Item.ExpiresAt > 0 {
+			if _, err := conn.Receive(); err != nil && errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return codes, errs
+}
+
+// fillErrors sets the error for every item of a batch which has not failed yet.
+func fillErrors(errs []error, err error) []error {
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+func (r repository) Exists(ctx context.Context, code string) (bool, error) {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	if _, err := r.key(conn, code); err == errItemNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r repository) FindOne(ctx context.Context, code string) (Item, error) {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	key, err := r.key(conn, code)
+	if err != nil {
+		return Item{}, err
+	}
+	values, err := redisClient.Values(conn.Do("HGETALL", key))
+	if err != nil {
+		return Item{}, err
+	}
+	var item Item
+	if err := redisClient.ScanStruct(values, &item); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+func (r repository) Update(ctx context.Context, code, URI string) error {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	key, err := r.key(conn, code)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Do("HSET", key, "url", URI)
+	return err
+}
+
+func (r repository) SetMetadata(ctx context.Context, code, title string, og OpenGraph) error {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	key, err := r.key(conn, code)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Do("HSET", key, "title", title, "og", og)
+	return err
+}
+
+func (r repository) Delete(ctx context.Context, code string) error {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	key, err := r.key(conn, code)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Do("DEL", key)
+	return err
+}
+
+// IncrClicks atomically counts a click of the link and returns the clicks so far.
+func (r repository) IncrClicks(ctx context.Context, code string) (int64, error) {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	key, err := r.key(conn, code)
+	if err != nil {
+		return 0, err
+	}
+	return redisClient.Int64(conn.Do("HINCRBY", key, "clicks", 1))
+}
+
+// SetState stores the state of the link, active links have none.
+func (r repository) SetState(ctx context.Context, code, state string) error {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	key, err := r.key(conn, code)
+	if err != nil {
+		return err
+	}
+	if state == LinkActive {
+		_, err = conn.Do("HDEL", key, "state")
+	} else {
+		_, err = conn.Do("HSET", key, "state", state)
+	}
+	return err
+}
+
+// AddDomain lets the domain serve the codes scoped by it.
+func (r repository) AddDomain(ctx context.Context, domain string) error {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SADD", domainsKey, domain)
+	return err
+}
+
+func (r repository) RemoveDomain(ctx context.Context, domain string) error {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SREM", domainsKey, domain)
+	return err
+}
+
+// HasDomain returns true if the domain is a verified branded domain.
+func (r repository) HasDomain(ctx context.Context, domain string) (bool, error) {
+	conn := r.redis.Pool.Get()
+	defer conn.Close()
+
+	return redisClient.Bool(conn.Do("SISMEMBER", domainsKey, domain))
+}
+
+// save stores the item in the given key and lets redis drop it a while after its expiration.
+func (r repository) save(conn redisClient.Conn, key string, item interface{}, expiresAt int64) error {
+	if _, err := conn.Do("HMSET", redisClient.Args{key}.AddFlat(item)...); err != nil {
+		return err
+	}
+	if expiresAt > 0 {
+		_, err := conn.Do("EXPIREAT", key, expiresAt+int64(expiredRetention.Seconds()))
+		return err
+	}
+	return nil
+}
+
+// key returns the redis key of the given code.
+// Suggested codes are stored as they are, random ones by their decoded id.
+// errItemNotFound is returned if neither is stored.
+func (r repository) key(conn redisClient.Conn, code string) (string, error) {
+	exists, err := redisClient.Bool(conn.Do("EXISTS", "Shortener:"+code))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "Shortener:" + code, nil
+	}
+	decodedId, err := base62.Decode(code)
+	if err != nil {
+		return "", errItemNotFound
+	}
+	key := "Shortener:" + strconv.FormatUint(decodedId, 10)
+	exists, err = redisClient.Bool(conn.Do("EXISTS", key))
+	if err != nil {
+		return "", err
+	} else if !exists {
+		return "", errItemNotFound
+	}
+	return key, nil
 }
 
 func (r repository) isIDUsed(ID uint64) bool {
@@ -2,87 +2,684 @@ package urlShortner
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 	"url/internal/config"
-	"url/internal/store"
+	"url/internal/errors"
 	"url/internal/track"
+	"url/pkg/canonical"
 	"url/pkg/log"
+	"url/pkg/policy"
+	"url/pkg/stringSuggestion"
 	"url/pkg/validators"
 )
 
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+
+	// trackerSalt salts the fingerprints of visitors.
+	trackerSalt = "salt"
+)
+
+var (
+	// ErrPasswordRequired is returned by Load when the link is protected and the visitor has not entered the password yet.
+	ErrPasswordRequired = errors.Unauthorized("the link is protected by a password")
+
+	// ErrNotYetActive is returned by Load before the activation window of the link when there is no inactive url.
+	ErrNotYetActive = errors.NotFound("the link is not available yet")
+
+	// ErrNoLongerActive is returned by Load after the activation window of the link when there is no inactive url.
+	ErrNoLongerActive = errors.Gone("the link is no longer available")
+)
+
 // Service encapsulates use case logic.
 type Service interface {
 	EnCode(ctx context.Context, dto InputDTO, userID int) (string, error)
-	Load(r *http.Request, url string) (string, error)
+	BatchEnCode(ctx context.Context, dtos []InputDTO, userID int) ([]BatchResult, error)
+	Import(ctx context.Context, r io.Reader, userID int) ([]BatchResult, error)
+	Export(ctx context.Context, w io.Writer, userID int) error
+	QR(ctx context.Context, code string, queries qrQueries, userID int) (QRImage, error)
+	Load(r *http.Request, url string) (Destination, error)
+	Preview(ctx context.Context, code string) (Preview, error)
+	SetOG(ctx context.Context, code string, og OpenGraph, userID int) (Link, error)
+	Unlock(r *http.Request, url string, password string) (Destination, *http.Cookie, error)
+	List(ctx context.Context, queries listQueries, userID int) (LinkPage, error)
+	Get(ctx context.Context, code string, userID int) (Link, error)
+	Update(ctx context.Context, code string, dto UpdateDTO, userID int) (Link, error)
+	History(ctx context.Context, code string, userID int) ([]LinkChange, error)
+	Delete(ctx context.Context, code string, userID int) error
+	ListTags(ctx context.Context, userID int) ([]Tag, error)
+	CreateTag(ctx context.Context, dto TagDTO, userID int) (Tag, error)
+	RenameTag(ctx context.Context, tagID int, dto TagDTO, userID int) (Tag, error)
+	DeleteTag(ctx context.Context, tagID int, userID int) error
+	SetLinkTags(ctx context.Context, code string, dto LinkTagsDTO, userID int) (Link, error)
+	ListFolders(ctx context.Context, userID int) ([]Folder, error)
+	CreateFolder(ctx context.Context, dto FolderDTO, userID int) (Folder, error)
+	RenameFolder(ctx context.Context, folderID int, dto FolderDTO, userID int) (Folder, error)
+	DeleteFolder(ctx context.Context, folderID int, userID int) error
+	SetLinkFolder(ctx context.Context, code string, dto LinkFolderDTO, userID int) (Link, error)
+	GetFallback(ctx context.Context, userID int) (Fallback, error)
+	SetFallback(ctx context.Context, dto FallbackDTO, userID int) (Fallback, error)
+	CodeFallback(ctx context.Context, code string) (Fallback, error)
+	ListDomains(ctx context.Context, userID int) ([]Domain, error)
+	AddDomain(ctx context.Context, dto DomainDTO, userID int) (Domain, error)
+	VerifyDomain(ctx context.Context, domainID int, userID int) (Domain, error)
+	DeleteDomain(ctx context.Context, domainID int, userID int) error
+	Scope(r *http.Request, code string) (string, error)
+	Disable(ctx context.Context, code string, userID int) (Link, error)
+	Enable(ctx context.Context, code string, userID int) (Link, error)
+	Report(r *http.Request, dto ReportDTO) error
+	ListReports(ctx context.Context, queries reportQueries) (ReportPage, error)
+	DismissReport(ctx context.Context, reportID int) error
+	Ban(ctx context.Context, code string, dto BanDTO) (Link, error)
+	Unban(ctx context.Context, code string) (Link, error)
+	IsAdmin(ctx context.Context, userID int) (bool, error)
+	Transfer(ctx context.Context, code string, dto TransferDTO, userID int) (TransferResult, error)
+	TransferAll(ctx context.Context, dto TransferDTO, userID int) (TransferResult, error)
+	AdminTransfer(ctx context.Context, dto AdminTransferDTO) (TransferResult, error)
+	Clone(ctx context.Context, code string, dto CloneDTO, userID int) (Link, error)
+	ListTrash(ctx context.Context, queries listQueries, userID int) (LinkPage, error)
+	Restore(ctx context.Context, code string, userID int) (Link, error)
+	Purge(ctx context.Context, code string, userID int) error
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
 }
 
 type InputDTO struct {
-	URL       string `json:"url" validate:"required,url"`
-	SimilarTo string `json:"similar_to"`
+	URL       string      `json:"url" validate:"required,url"`
+	SimilarTo string      `json:"similar_to"`
+	Alias     string      `json:"alias"`
+	ForceNew  bool        `json:"force_new"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+	MaxClicks int64       `json:"max_clicks" validate:"min=0"`
+	Password  string      `json:"password" validate:"omitempty,max=72"`
+	Status    int         `json:"redirect_status" validate:"omitempty,oneof=301 302 307 308"`
+	Targets   TargetRules `json:"targets" validate:"omitempty,dive"`
+	Variants  Variants    `json:"variants" validate:"omitempty,dive"`
+	Tags      []string    `json:"tags" validate:"omitempty,max=20,dive,min=1,max=50"`
+	FolderID  *int        `json:"folder_id"`
+	// ActiveFrom and ActiveUntil limit when the link redirects, InactiveURL is where visitors go outside of it.
+	ActiveFrom  *time.Time `json:"active_from"`
+	ActiveUntil *time.Time `json:"active_until"`
+	InactiveURL string     `json:"inactive_url" validate:"omitempty,url"`
+	// Description is shown on the preview page, which every visitor of an Interstitial link sees first.
+	Description  string `json:"description" validate:"max=500"`
+	Interstitial bool   `json:"interstitial"`
+	// OG overrides the metadata fetched from the destination, which is shown when the link is shared.
+	OG OpenGraph `json:"og"`
+	// UTM parameters are added to the destination.
+	UTM
+	// Domain is the verified branded domain of the user which serves the link, by default the server domain.
+	Domain string `json:"domain" validate:"omitempty,fqdn,max=253"`
+}
+
+// isPlain returns true if the request does not customize the link beyond its url.
+func (req InputDTO) isPlain() bool {
+	return req.SimilarTo == "" && req.Alias == "" && req.ExpiresAt == nil && req.MaxClicks == 0 &&
+		req.Password == "" && req.Status == 0 && len(req.Targets) == 0 && len(req.Variants) == 0 && len(req.Tags) == 0 &&
+		req.FolderID == nil && req.ActiveFrom == nil && req.ActiveUntil == nil && req.InactiveURL == "" &&
+		req.Description == "" && !req.Interstitial && req.OG.IsEmpty() &&
+		req.UTM.IsEmpty() && req.Domain == ""
+}
+
+type UpdateDTO struct {
+	URL string `json:"url" validate:"required,url"`
+}
+
+type listQueries struct {
+	Page    string
+	PerPage string
+	Search  string
+	From    string
+	To      string
+	Tag     string
+	Folder  string
 }
 
 type service struct {
-	repo    Repository
-	store   Store
-	logger  log.Logger
-	tracker *track.Tracker
+	repo     Repository
+	store    Store
+	logger   log.Logger
+	tracker  *track.Tracker
+	geoDB    *track.GeoDB
+	policy   *policy.Policy
+	client   HTTPClient
+	resolver Resolver
 }
 
 // NewService creates a new service.
-func NewService(trackerStore *store.PostgresStore, store Store, repo Repository, logger log.Logger) Service {
-	tracker := track.NewTracker(trackerStore, "salt", &track.TrackerConfig{Logger: logger})
-	return service{repo, store, logger, tracker}
+// The geoDB is optional, without it the country of visitors is unknown.
+// The policy limits the destinations of links, without it the default policy is used.
+// The client fetches the metadata of destinations, without it a client which does not connect to private networks is used.
+// The resolver verifies the branded domains, without it the default resolver is used.
+func NewService(trackerStore track.Store, store Store, repo Repository, geoDB *track.GeoDB, destinations *policy.Policy, client HTTPClient, resolver Resolver, logger log.Logger) Service {
+	if destinations == nil {
+		destinations = policy.New(policy.Config{})
+	}
+	if client == nil {
+		client = newMetadataClient(destinations)
+	}
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	tracker := track.NewTracker(trackerStore, trackerSalt, &track.TrackerConfig{Logger: logger})
+	if geoDB != nil {
+		tracker.SetGeoDB(geoDB)
+	}
+	return service{repo, store, logger, tracker, geoDB, destinations, client, resolver}
 }
 
 func (s service) EnCode(ctx context.Context, req InputDTO, userID int) (string, error) {
-	if ok, err := validators.Validate(req); !ok {
+	item, link, existing, err := s.prepare(ctx, req, userID)
+	if err != nil {
 		return "", err
 	}
+	if existing != "" {
+		return shortURL(existing), nil
+	}
+	// generate link and save
+	if req.Alias != "" {
+		alias := scopeCode(req.Alias, link.Domain)
+		claimed, err := s.repo.Claim(ctx, item, alias)
+		if err != nil {
+			return "", err
+		}
+		if !claimed {
+			return "", s.aliasConflict(ctx, req.Alias, link.Domain)
+		}
+		link.Code = alias
+	} else {
+		link.Code, err = s.repo.Create(ctx, item, req.SimilarTo, link.Domain)
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := s.createLink(userID, link); err != nil {
+		// the code is not saved, so it must not resolve either
+		if err := s.repo.Delete(ctx, link.Code); err != nil {
+			s.logger.With(ctx).Errorf("failed deleting code %s of a failed link: %s", link.Code, err)
+		}
+		return "", s.codeError(ctx, err, req.Alias, link.Domain)
+	}
+	s.fetchMetadata([]Link{link})
+	return shortURL(link.Code), nil
+}
+
+// prepare validates the request and builds the item and the link to be saved.
+// If the user already has the same link, its code is returned instead.
+func (s service) prepare(ctx context.Context, req InputDTO, userID int) (Item, Link, string, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Item{}, Link{}, "", err
+	}
+	if req.Alias != "" {
+		if req.SimilarTo != "" {
+			return Item{}, Link{}, "", errors.BadRequest("alias and similar_to can not be used together")
+		}
+		if err := validateAlias(req.Alias); err != nil {
+			return Item{}, Link{}, "", err
+		}
+	}
+	if err := s.checkDestinations(ctx, req); err != nil {
+		return Item{}, Link{}, "", err
+	}
 	URI, err := url.ParseRequestURI(req.URL)
 	if err != nil {
-		return "", err
+		return Item{}, Link{}, "", errors.BadRequest(err.Error())
 	}
-	// generate link and save
-	path, err := s.repo.Create(ctx, URI.String(), req.SimilarTo)
+	req.UTM.AddTo(URI)
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return Item{}, Link{}, "", errors.BadRequest("expires_at must be in the future")
+	}
+	if req.ActiveFrom != nil && req.ActiveUntil != nil && !req.ActiveUntil.After(*req.ActiveFrom) {
+		return Item{}, Link{}, "", errors.BadRequest("active_until must be after active_from")
+	}
+	if err := s.checkFolder(req.FolderID, userID); err != nil {
+		return Item{}, Link{}, "", err
+	}
+	domain := ""
+	if req.Domain != "" {
+		if domain, err = s.checkDomain(req.Domain, userID); err != nil {
+			return Item{}, Link{}, "", err
+		}
+	}
+	canonicalURL, err := canonical.URL(URI.String(), config.Cfg.Options.StripTracking)
 	if err != nil {
-		return "", err
+		return Item{}, Link{}, "", errors.BadRequest(err.Error())
 	}
-	u := url.URL{
-		Scheme: config.Cfg.Options.Schema,
-		Host:   config.Cfg.Options.BaseURL,
-		Path:   path,
+	// the same url shortened again by the user gets its existing link
+	if !req.ForceNew && req.isPlain() {
+		existing, err := s.store.FindUserLinkByURL(nil, userID, canonicalURL)
+		if err == nil {
+			return Item{}, Link{}, existing.Code, nil
+		} else if err != sql.ErrNoRows {
+			return Item{}, Link{}, "", err
+		}
 	}
-	if err := s.createLink(userID, req.URL, path); err != nil {
-		return "", err
+	item := Item{URL: URI.String(), MaxClicks: req.MaxClicks, Status: req.Status, Targets: req.Targets, Variants: req.Variants}
+	link := Link{URL: URI.String(), CanonicalURL: canonicalURL, ExpiresAt: req.ExpiresAt, Targets: req.Targets, Variants: req.Variants, Tags: req.Tags, FolderID: req.FolderID, Domain: domain}
+	if req.ExpiresAt != nil {
+		item.ExpiresAt = req.ExpiresAt.Unix()
+	}
+	if req.MaxClicks > 0 {
+		link.MaxClicks = &req.MaxClicks
+	}
+	if req.Status != 0 {
+		link.Status = &req.Status
+	}
+	if req.ActiveFrom != nil {
+		item.ActiveFrom = req.ActiveFrom.Unix()
+		link.ActiveFrom = req.ActiveFrom
+	}
+	if req.ActiveUntil != nil {
+		item.ActiveUntil = req.ActiveUntil.Unix()
+		link.ActiveUntil = req.ActiveUntil
+	}
+	if req.InactiveURL != "" {
+		item.InactiveURL = req.InactiveURL
+		link.InactiveURL = &req.InactiveURL
+	}
+	if req.Description != "" {
+		item.Description = req.Description
+		link.Description = &req.Description
 	}
-	return u.String(), nil
+	item.Interstitial, link.Interstitial = req.Interstitial, req.Interstitial
+	item.OG, link.OG = req.OG, req.OG
+	link.UTM = req.UTM
+	if req.Password != "" {
+		// hash password
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 10)
+		if err != nil {
+			return Item{}, Link{}, "", err
+		}
+		item.Password = string(hashedPassword)
+		link.Password = &item.Password
+	}
+	return item, link, "", nil
 }
 
-func (s service) Load(request *http.Request, url string) (string, error) {
-	uri, err := s.repo.FindOne(request.Context(), url)
+func (s service) Load(request *http.Request, url string) (Destination, error) {
+	item, err := s.repo.FindOne(request.Context(), url)
 	if err != nil {
-		return "", err
+		return Destination{}, err
+	}
+	if err := checkState(item); err != nil {
+		return Destination{}, err
+	}
+	if err := checkExpired(item); err != nil {
+		return Destination{}, err
+	}
+	if dest, err := checkActive(item); err != nil || dest.URL != "" {
+		return dest, err
+	}
+	if item.Password != "" && !validPasswordCookie(request, url, item.Password, config.Cfg.Options.CookieSecret) {
+		return Destination{}, ErrPasswordRequired
+	}
+	return s.resolve(request, url, item)
+}
+
+func (s service) Unlock(request *http.Request, url, password string) (Destination, *http.Cookie, error) {
+	item, err := s.repo.FindOne(request.Context(), url)
+	if err != nil {
+		return Destination{}, nil, err
+	}
+	if err := checkState(item); err != nil {
+		return Destination{}, nil, err
+	}
+	if err := checkExpired(item); err != nil {
+		return Destination{}, nil, err
+	}
+	if dest, err := checkActive(item); err != nil || dest.URL != "" {
+		return dest, nil, err
+	}
+	if item.Password == "" {
+		dest, err := s.resolve(request, url, item)
+		return dest, nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(item.Password), []byte(password)) != nil {
+		return Destination{}, nil, errors.Unauthorized("incorrect password")
+	}
+	dest, err := s.resolve(request, url, item)
+	if err != nil {
+		return Destination{}, nil, err
+	}
+	return dest, newPasswordCookie(url, item.Password, config.Cfg.Options.CookieSecret), nil
+}
+
+// resolve counts the click of a visitor who is allowed to follow the link and returns the destination.
+func (s service) resolve(request *http.Request, url string, item Item) (Destination, error) {
+	if item.MaxClicks > 0 {
+		clicks, err := s.repo.IncrClicks(request.Context(), url)
+		if err != nil {
+			return Destination{}, err
+		}
+		if clicks > item.MaxClicks {
+			return Destination{}, errMaxClicks
+		}
+	}
+	dest := Destination{URL: item.URL, Status: item.Status}
+	if dest.Status == 0 {
+		dest.Status = config.Cfg.Options.RedirectStatus
+	}
+	// hits are tracked by the code, the same as links.shortner_path, so the analytics can join them with the links
+	options := &track.HitOptions{Path: url}
+	if request.URL.Query().Get(sourceParam) == SourceQR {
+		options.Source = SourceQR
 	}
-	go s.track(request)
-	return uri, nil
+	countryCode := ""
+	if s.geoDB != nil && item.Targets.HasCountries() {
+		countryCode = s.geoDB.RequestCountryCode(request)
+	}
+	if rule, ok := item.Targets.Find(track.ParseUserAgent(request.UserAgent()), countryCode); ok {
+		dest.URL = rule.URL
+		options.Target = rule.String()
+	} else if variant, ok := item.Variants.Pick(track.VisitorKey(request, trackerSalt)); ok {
+		dest.URL = variant.URL
+		options.Variant = variant.Name
+	}
+	go s.track(request, options)
+	if item.Interstitial {
+		dest.Interstitial = &Preview{Code: url, ShortURL: shortURL(url), URL: dest.URL, Title: item.Title, Description: item.Description}
+	}
+	return dest, nil
 }
 
-func (s service) track(r *http.Request) {
-	s.tracker.Hit(r, nil)
+func (s service) List(ctx context.Context, queries listQueries, userID int) (LinkPage, error) {
+	filter, page, perPage, err := s.listQueriesValidator(queries)
+	if err != nil {
+		return LinkPage{}, errors.BadRequest(err.Error())
+	}
+	links, total, err := s.store.FindUserLinks(nil, userID, filter)
+	if err != nil {
+		return LinkPage{}, err
+	}
+	for i := range links {
+		links[i].ShortURL = shortURL(links[i].Code)
+	}
+	return LinkPage{
+		Items:   links,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	}, nil
+}
+
+func (s service) Get(ctx context.Context, code string, userID int) (Link, error) {
+	link, err := s.store.FindUserLink(nil, userID, code)
+	if err == sql.ErrNoRows {
+		return Link{}, errors.NotFound("")
+	} else if err != nil {
+		return Link{}, err
+	}
+	link.ShortURL = shortURL(link.Code)
+	return link, nil
 }
 
-func (s service) createLink(userID int, url, path string) error {
+func (s service) Update(ctx context.Context, code string, req UpdateDTO, userID int) (Link, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Link{}, err
+	}
+	if v := s.checkDestination(ctx, req.URL); v != nil {
+		return Link{}, errors.InvalidFields([]errors.FieldError{{Field: "url", Rule: v.Rule, Error: v.Message}})
+	}
+	URI, err := url.ParseRequestURI(req.URL)
+	if err != nil {
+		return Link{}, errors.BadRequest(err.Error())
+	}
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	// the utm parameters of the link are added to the new destination like they were to the first one
+	link.UTM.AddTo(URI)
+	canonicalURL, err := canonical.URL(URI.String(), config.Cfg.Options.StripTracking)
+	if err != nil {
+		return Link{}, errors.BadRequest(err.Error())
+	}
+	if link.URL == URI.String() {
+		return link, nil
+	}
+	// redis is updated last, so a failed update leaves both stores untouched
 	tx := s.store.NewTx()
-	linkID, err := s.store.CreateLink(tx, url, path)
+	if err := s.store.UpdateLinkURL(tx, link.ID, URI.String(), canonicalURL); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	if err := s.store.AddLinkChange(tx, link.ID, userID, link.URL, URI.String()); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	// the metadata of the previous destination does not describe the new one, it is fetched again
+	if err := s.store.UpdateLinkMetadata(tx, link.Code, "", OpenGraph{}); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	if err := s.updateItem(ctx, link, URI.String()); err != nil {
+		s.store.Rollback(tx)
+		return Link{}, err
+	}
+	s.store.Commit(tx)
+	updated, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	s.fetchMetadata([]Link{updated})
+	return updated, nil
+}
+
+// updateItem changes the destination of the redis item of the link and clears the metadata of the previous one.
+// Redis drops links a while after their expiration, or when it runs out of memory, the item is stored again then.
+func (s service) updateItem(ctx context.Context, link Link, URI string) error {
+	err := s.repo.SetMetadata(ctx, link.Code, "", link.OG)
+	if err == nil {
+		err = s.repo.Update(ctx, link.Code, URI)
+	}
+	if err != errItemNotFound {
+		return err
+	}
+	link.URL, link.Title, link.FetchedOG = URI, nil, OpenGraph{}
+	claimed, err := s.repo.Claim(ctx, newItem(link, 0), link.Code)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errors.Conflict("the code of the link is used by another link")
+	}
+	return nil
+}
+
+// History returns the previous destinations of the link.
+func (s service) History(ctx context.Context, code string, userID int) ([]LinkChange, error) {
+	link, err := s.Get(ctx, code, userID)
 	if err != nil {
+		return nil, err
+	}
+	return s.store.FindLinkChanges(nil, link.ID)
+}
+
+// Delete moves the link into the trash, it stops redirecting but keeps its hits until it is purged.
+func (s service) Delete(ctx context.Context, code string, userID int) error {
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return err
+	}
+	// the clicks are counted in redis only, they are kept to limit the clicks of the link once it is restored.
+	// Redis drops expired links a while after their expiration, their clicks are gone with them.
+	item, err := s.repo.FindOne(ctx, link.Code)
+	stored := err == nil
+	if err != nil && err != errItemNotFound {
+		return err
+	}
+	tx := s.store.NewTx()
+	if err := s.store.SoftDeleteLink(tx, link.ID, item.Clicks); err != nil {
 		s.store.Rollback(tx)
 		return err
 	}
-	if err := s.store.CreateUserLinkRelation(tx, userID, linkID); err != nil {
+	if stored {
+		if err := s.repo.Delete(ctx, link.Code); err != nil {
+			s.store.Rollback(tx)
+			return err
+		}
+	}
+	s.store.Commit(tx)
+	return nil
+}
+
+func (s service) track(r *http.Request, options *track.HitOptions) {
+	s.tracker.Hit(r, options)
+}
+
+func (s service) createLink(userID int, link Link) error {
+	tx := s.store.NewTx()
+	if err := s.saveLink(tx, userID, link); err != nil {
 		s.store.Rollback(tx)
 		return err
 	}
 	s.store.Commit(tx)
 	return nil
 }
+
+// saveLink saves the link, its relation to the user and its tags in the transaction.
+func (s service) saveLink(tx *sqlx.Tx, userID int, link Link) error {
+	linkID, err := s.store.CreateLink(tx, link)
+	if err != nil {
+		return err
+	}
+	if err := s.store.CreateUserLinkRelation(tx, userID, linkID); err != nil {
+		return err
+	}
+	if len(link.Tags) > 0 {
+		return s.store.AttachTags(tx, userID, linkID, link.Tags)
+	}
+	return nil
+}
+
+// aliasConflict returns the conflict error of a taken alias of the domain with free suggestions.
+func (s service) aliasConflict(ctx context.Context, alias, domain string) error {
+	conflict := errors.Conflict(fmt.Sprintf("alias %s is already taken", alias))
+	conflict.Details = aliasConflictDetails{Suggestions: s.suggestAliases(ctx, alias, domain)}
+	return conflict
+}
+
+// codeError converts the unique violation of a code which is kept by a link in the trash into a conflict.
+func (s service) codeError(ctx context.Context, err error, alias, domain string) error {
+	if !isUniqueViolation(err) {
+		return err
+	}
+	if alias != "" {
+		return s.aliasConflict(ctx, alias, domain)
+	}
+	return errors.Conflict("the code is used by a deleted link, please try again")
+}
+
+// suggestAliases returns free alternatives on the domain similar to the taken alias.
+func (s service) suggestAliases(ctx context.Context, alias, domain string) []string {
+	suggestions := make([]string, 0, aliasSuggestions)
+	for i := 0; i < aliasSuggestions*10 && len(suggestions) < aliasSuggestions; i++ {
+		suggestion := stringSuggestion.Suggest(alias, 2, len(alias))
+		if validateAlias(suggestion) != nil || containsString(suggestions, suggestion) {
+			continue
+		}
+		if used, err := s.repo.Exists(ctx, scopeCode(suggestion, domain)); err != nil || used {
+			continue
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
+// checkActive returns the inactive url of the link as destination when it is used outside its activation window.
+// If neither the link nor the server has an inactive url, an error is returned instead.
+func checkActive(item Item) (Destination, error) {
+	now := time.Now().Unix()
+	var inactive error
+	if item.ActiveFrom > 0 && now < item.ActiveFrom {
+		inactive = ErrNotYetActive
+	} else if item.ActiveUntil > 0 && now >= item.ActiveUntil {
+		inactive = ErrNoLongerActive
+	} else {
+		return Destination{}, nil
+	}
+	inactiveURL := item.InactiveURL
+	if inactiveURL == "" {
+		inactiveURL = config.Cfg.Options.InactiveURL
+	}
+	if inactiveURL == "" {
+		return Destination{}, inactive
+	}
+	// the link becomes active later, so browsers must not cache the redirect
+	return Destination{URL: inactiveURL, Status: http.StatusFound}, nil
+}
+
+// checkExpired returns a gone error if the link has passed its expiration date.
+func checkExpired(item Item) error {
+	if item.ExpiresAt > 0 && time.Now().Unix() >= item.ExpiresAt {
+		return errors.Gone("the link has expired")
+	}
+	return nil
+}
+
+// errMaxClicks is returned for the links which have reached their maximum clicks.
+var errMaxClicks = errors.Gone("the link has reached its maximum clicks")
+
+// checkClicks returns an error if the link has reached its maximum clicks, without counting a click.
+func checkClicks(item Item) error {
+	if item.MaxClicks > 0 && item.Clicks >= item.MaxClicks {
+		return errMaxClicks
+	}
+	return nil
+}
+
+func (s service) listQueriesValidator(queries listQueries) (LinkFilter, int, int, error) {
+	page, err := strconv.Atoi(queries.Page)
+	if err != nil || page < 1 {
+		return LinkFilter{}, 0, 0, fmt.Errorf("enter the correct page, %s is not a positive number", queries.Page)
+	}
+	perPage, err := strconv.Atoi(queries.PerPage)
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return LinkFilter{}, 0, 0, fmt.Errorf("enter the correct per_page, %s is not between 1 and %d", queries.PerPage, maxPerPage)
+	}
+	filter := LinkFilter{
+		Search: queries.Search,
+		Tag:    queries.Tag,
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	}
+	if queries.Folder != "" {
+		if filter.FolderID, err = strconv.Atoi(queries.Folder); err != nil || filter.FolderID < 1 {
+			return LinkFilter{}, 0, 0, fmt.Errorf("enter the correct folder, %s is not a folder id", queries.Folder)
+		}
+	}
+	if queries.From != "" {
+		if filter.From, err = time.Parse(time.RFC3339, queries.From); err != nil {
+			return LinkFilter{}, 0, 0, fmt.Errorf("enter the correct from date, %s is not RFC3339", queries.From)
+		}
+	}
+	if queries.To != "" {
+		if filter.To, err = time.Parse(time.RFC3339, queries.To); err != nil {
+			return LinkFilter{}, 0, 0, fmt.Errorf("enter the correct to date, %s is not RFC3339", queries.To)
+		}
+	}
+	return filter, page, perPage, nil
+}
+
+// shortURL builds the full short url of the given path, paths scoped by a branded domain are on that domain.
+func shortURL(path string) string {
+	code, domain := splitCode(path)
+	if domain == "" {
+		domain = config.Cfg.Options.BaseURL
+	}
+	u := url.URL{
+		Scheme: config.Cfg.Options.Schema,
+		Host:   domain,
+		Path:   code,
+	}
+	return u.String()
+}
@@ -0,0 +1,265 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"os"
+	"testing"
+	"url/internal/config"
+	"url/pkg/log"
+	"url/pkg/policy"
+)
+
+// stubStore keeps the links of a single user in memory, the methods the tests do not need panic.
+type stubStore struct {
+	Store
+	links   map[string]Link
+	deleted map[int]int64
+	reports []LinkReport
+}
+
+func newStubStore(links ...Link) *stubStore {
+	s := &stubStore{links: make(map[string]Link), deleted: make(map[int]int64)}
+	for _, link := range links {
+		s.links[link.Code] = link
+	}
+	return s
+}
+
+func (s *stubStore) NewTx() *sqlx.Tx   { return nil }
+func (s *stubStore) Commit(*sqlx.Tx)   {}
+func (s *stubStore) Rollback(*sqlx.Tx) {}
+
+func (s *stubStore) FindUserLink(tx *sqlx.Tx, userID int, code string) (Link, error) {
+	link, ok := s.links[code]
+	if !ok {
+		return Link{}, sql.ErrNoRows
+	}
+	return link, nil
+}
+
+func (s *stubStore) CreateLink(tx *sqlx.Tx, link Link) (int, error) {
+	if _, ok := s.links[link.Code]; ok {
+		return 0, errors.New("duplicate code")
+	}
+	link.ID = len(s.links) + 1
+	s.links[link.Code] = link
+	return link.ID, nil
+}
+
+func (s *stubStore) CreateUserLinkRelation(tx *sqlx.Tx, userID, linkID int) error {
+	return nil
+}
+
+func (s *stubStore) CopyHits(tx *sqlx.Tx, from, to string) error {
+	return nil
+}
+
+func (s *stubStore) FindLink(tx *sqlx.Tx, code string) (Link, error) {
+	return s.FindUserLink(tx, 0, code)
+}
+
+func (s *stubStore) SetLinkState(tx *sqlx.Tx, linkID int, state string, reason *string) error {
+	for code, link := range s.links {
+		if link.ID == linkID {
+			link.State = state
+			s.links[code] = link
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *stubStore) ReviewLinkReports(tx *sqlx.Tx, linkID int) error {
+	return nil
+}
+
+func (s *stubStore) CreateReport(tx *sqlx.Tx, report LinkReport) error {
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func (s *stubStore) UpdateLinkURL(tx *sqlx.Tx, linkID int, url, canonicalURL string) error {
+	for code, link := range s.links {
+		if link.ID == linkID {
+			link.URL, link.CanonicalURL = url, canonicalURL
+			s.links[code] = link
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *stubStore) AddLinkChange(tx *sqlx.Tx, linkID, userID int, previous, url string) error {
+	return nil
+}
+
+func (s *stubStore) UpdateLinkMetadata(tx *sqlx.Tx, code, title string, og OpenGraph) error {
+	return nil
+}
+
+func (s *stubStore) SoftDeleteLink(tx *sqlx.Tx, linkID int, clicks int64) error {
+	s.deleted[linkID] = clicks
+	return nil
+}
+
+// stubRepository keeps the items in memory like redis keeps them, the methods the tests do not need panic.
+type stubRepository struct {
+	Repository
+	items   map[string]Item
+	domains map[string]bool
+}
+
+func newStubRepository(items map[string]Item) *stubRepository {
+	if items == nil {
+		items = make(map[string]Item)
+	}
+	return &stubRepository{items: items, domains: make(map[string]bool)}
+}
+
+func (r *stubRepository) FindOne(ctx context.Context, code string) (Item, error) {
+	item, ok := r.items[code]
+	if !ok {
+		return Item{}, errItemNotFound
+	}
+	return item, nil
+}
+
+func (r *stubRepository) Claim(ctx context.Context, item Item, alias string) (bool, error) {
+	if _, ok := r.items[alias]; ok {
+		return false, nil
+	}
+	r.items[alias] = item
+	return true, nil
+}
+
+func (r *stubRepository) Update(ctx context.Context, code, URI string) error {
+	item, ok := r.items[code]
+	if !ok {
+		return errItemNotFound
+	}
+	item.URL = URI
+	r.items[code] = item
+	return nil
+}
+
+func (r *stubRepository) SetMetadata(ctx context.Context, code, title string, og OpenGraph) error {
+	item, ok := r.items[code]
+	if !ok {
+		return errItemNotFound
+	}
+	item.Title, item.OG = title, og
+	r.items[code] = item
+	return nil
+}
+
+func (r *stubRepository) Delete(ctx context.Context, code string) error {
+	if _, ok := r.items[code]; !ok {
+		return errItemNotFound
+	}
+	delete(r.items, code)
+	return nil
+}
+
+func (r *stubRepository) SetState(ctx context.Context, code, state string) error {
+	item, ok := r.items[code]
+	if !ok {
+		return errItemNotFound
+	}
+	item.State = state
+	r.items[code] = item
+	return nil
+}
+
+func (r *stubRepository) HasDomain(ctx context.Context, domain string) (bool, error) {
+	return r.domains[domain], nil
+}
+
+func TestMain(m *testing.M) {
+	config.Cfg = &config.Config{}
+	config.Cfg.Options.BaseURL = "sho.rt"
+	config.Cfg.Options.Schema = "https"
+	os.Exit(m.Run())
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		link  Link
+		items map[string]Item
+		want  string
+	}{
+		{
+			name:  "stored link",
+			link:  Link{ID: 1, Code: "abc", URL: "https://example.com/old"},
+			items: map[string]Item{"abc": {URL: "https://example.com/old", Title: "Old"}},
+			want:  "https://example.com/new?a=1",
+		},
+		{
+			name: "link dropped by redis",
+			link: Link{ID: 1, Code: "abc", URL: "https://example.com/old"},
+			want: "https://example.com/new?a=1",
+		},
+		{
+			name:  "utm parameters of the link",
+			link:  Link{ID: 1, Code: "abc", URL: "https://example.com/old?utm_source=news", UTM: UTM{Source: "news"}},
+			items: map[string]Item{"abc": {URL: "https://example.com/old?utm_source=news"}},
+			want:  "https://example.com/new?a=1&utm_source=news",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := log.NewForTest()
+			store := newStubStore(tt.link)
+			repo := newStubRepository(tt.items)
+			s := service{store: store, repo: repo, logger: logger, policy: policy.New(policy.Config{}), client: stubClient{err: errors.New("offline")}}
+			link, err := s.Update(context.Background(), "abc", UpdateDTO{URL: "https://example.com/new?a=1"}, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if link.URL != tt.want {
+				t.Errorf("got link url %s, want %s", link.URL, tt.want)
+			}
+			item, ok := repo.items["abc"]
+			if !ok {
+				t.Fatal("the link does not redirect")
+			}
+			if item.URL != tt.want || item.Title != "" {
+				t.Errorf("got item %+v, want url %s without title", item, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		items  map[string]Item
+		clicks int64
+	}{
+		{"stored link", map[string]Item{"abc": {URL: "https://example.com", Clicks: 7}}, 7},
+		{"link dropped by redis", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStubStore(Link{ID: 1, Code: "abc", URL: "https://example.com"})
+			repo := newStubRepository(tt.items)
+			s := service{store: store, repo: repo}
+			if err := s.Delete(context.Background(), "abc", 1); err != nil {
+				t.Fatal(err)
+			}
+			clicks, ok := store.deleted[1]
+			if !ok {
+				t.Fatal("the link is not in the trash")
+			}
+			if clicks != tt.clicks {
+				t.Errorf("got %d clicks kept, want %d", clicks, tt.clicks)
+			}
+			if _, ok := repo.items["abc"]; ok {
+				t.Error("the link still redirects")
+			}
+		})
+	}
+}
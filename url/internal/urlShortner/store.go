@@ -2,6 +2,7 @@ package urlShortner
 
 import (
 	"github.com/jmoiron/sqlx"
+	"time"
 )
 
 // Store defines an interface to persists hits and other data.
@@ -15,9 +16,161 @@ type Store interface {
 	// Rollback rolls back given transaction and logs the error.
 	Rollback(*sqlx.Tx)
 
-	// CreateUser create new link.
-	CreateLink(*sqlx.Tx, string, string) (int, error)
+	// Savepoint creates a savepoint, so the following statements can be rolled back without the whole transaction.
+	Savepoint(*sqlx.Tx, string) error
+
+	// RollbackToSavepoint rolls back the transaction to the savepoint.
+	RollbackToSavepoint(*sqlx.Tx, string) error
+
+	// CreateLink create new link.
+	CreateLink(*sqlx.Tx, Link) (int, error)
 
 	// CreateUserLinkRelation create relation between user and link
 	CreateUserLinkRelation(*sqlx.Tx, int, int) error
+
+	// AttachTags attaches the tags to the link, the tags are created for the user if needed.
+	AttachTags(*sqlx.Tx, int, int, []string) error
+
+	// ReplaceTags replaces the tags of the link, the tags are created for the user if needed.
+	ReplaceTags(*sqlx.Tx, int, int, []string) error
+
+	// FindUserTags returns the tags of the user with the count of their links.
+	FindUserTags(*sqlx.Tx, int) ([]Tag, error)
+
+	// FindUserTag returns the tag of the user by its id.
+	FindUserTag(*sqlx.Tx, int, int) (Tag, error)
+
+	// CreateTag creates a tag for the user.
+	CreateTag(*sqlx.Tx, int, string) (int, error)
+
+	// RenameTag renames the tag of the user, sql.ErrNoRows is returned if the user has no such tag.
+	RenameTag(*sqlx.Tx, int, int, string) error
+
+	// DeleteTag removes the tag of the user from its links and deletes it, sql.ErrNoRows is returned if the user has no such tag.
+	DeleteTag(*sqlx.Tx, int, int) error
+
+	// FindUserFolders returns the folders of the user with the count of their links.
+	FindUserFolders(*sqlx.Tx, int) ([]Folder, error)
+
+	// FindUserFolder returns the folder of the user by its id.
+	FindUserFolder(*sqlx.Tx, int, int) (Folder, error)
+
+	// CreateFolder creates a folder for the user.
+	CreateFolder(*sqlx.Tx, int, string) (int, error)
+
+	// RenameFolder renames the folder of the user, sql.ErrNoRows is returned if the user has no such folder.
+	RenameFolder(*sqlx.Tx, int, int, string) error
+
+	// DeleteFolder moves the links of the folder out of it and deletes it, sql.ErrNoRows is returned if the user has no such folder.
+	DeleteFolder(*sqlx.Tx, int, int) error
+
+	// MoveLink moves the link into the folder, or out of any folder if it is nil.
+	MoveLink(*sqlx.Tx, int, *int) error
+
+	// StreamUserLinks calls the function for each link of the user with its clicks, without loading all of them.
+	StreamUserLinks(*sqlx.Tx, int, func(LinkClicks) error) error
+
+	// FindUserLinks returns the links of the user matching the filter and the total count of them.
+	FindUserLinks(*sqlx.Tx, int, LinkFilter) ([]Link, int, error)
+
+	// FindUserLink returns the link of the user by its code.
+	FindUserLink(*sqlx.Tx, int, string) (Link, error)
+
+	// FindUserTrashedLink returns the link of the user in the trash by its code.
+	FindUserTrashedLink(*sqlx.Tx, int, string) (Link, error)
+
+	// FindUserLinkByURL returns the newest link of the user to the canonical url which has no options set.
+	FindUserLinkByURL(*sqlx.Tx, int, string) (Link, error)
+
+	// UpdateLinkURL changes the destination and the canonical url of the link.
+	UpdateLinkURL(*sqlx.Tx, int, string, string) error
+
+	// UpdateLinkMetadata saves the title and the OpenGraph metadata fetched from the destination of the link with the code.
+	UpdateLinkMetadata(*sqlx.Tx, string, string, OpenGraph) error
+
+	// UpdateLinkOG saves the OpenGraph metadata set by the owner of the link.
+	UpdateLinkOG(*sqlx.Tx, int, OpenGraph) error
+
+	// AddLinkChange records that the user changed the destination of the link from the previous url to the url.
+	AddLinkChange(*sqlx.Tx, int, int, string, string) error
+
+	// FindLinkChanges returns the destination changes of the link, the newest first.
+	FindLinkChanges(*sqlx.Tx, int) ([]LinkChange, error)
+
+	// FindUserFallback returns the fallback of the user.
+	FindUserFallback(*sqlx.Tx, int) (Fallback, error)
+
+	// SaveUserFallback creates or replaces the fallback of the user.
+	SaveUserFallback(*sqlx.Tx, int, Fallback) error
+
+	// FindCodeFallback returns the fallback of the owner of the link with the code.
+	FindCodeFallback(*sqlx.Tx, string) (Fallback, error)
+
+	// FindUserDomains returns the branded domains of the user.
+	FindUserDomains(*sqlx.Tx, int) ([]Domain, error)
+
+	// FindUserDomain returns the domain of the user by its id.
+	FindUserDomain(*sqlx.Tx, int, int) (Domain, error)
+
+	// FindUserDomainByName returns the domain of the user by its name.
+	FindUserDomainByName(*sqlx.Tx, int, string) (Domain, error)
+
+	// CreateDomain creates an unverified domain for the user with the verification token.
+	CreateDomain(*sqlx.Tx, int, string, string) (int, error)
+
+	// VerifyDomain marks the domain as verified.
+	VerifyDomain(*sqlx.Tx, int) error
+
+	// DeleteDomain deletes the domain of the user, sql.ErrNoRows is returned if the user has no such domain.
+	DeleteDomain(*sqlx.Tx, int, int) error
+
+	// DomainHasLinks returns true if any link is on the domain.
+	DomainHasLinks(*sqlx.Tx, string) (bool, error)
+
+	// FindLink returns the link of any user by its code.
+	FindLink(*sqlx.Tx, string) (Link, error)
+
+	// SetLinkState changes the state of the link, the ban reason is only changed if it is not nil.
+	SetLinkState(*sqlx.Tx, int, string, *string) error
+
+	// CreateReport saves the abuse report, a second report of the same fingerprint for the link on the same day is ignored.
+	CreateReport(*sqlx.Tx, LinkReport) error
+
+	// FindReports returns the reviewed or the open reports, the newest first, and the total count of them.
+	FindReports(*sqlx.Tx, bool, int, int) ([]LinkReport, int, error)
+
+	// ReviewReport marks the report as reviewed, sql.ErrNoRows is returned if there is no such open report.
+	ReviewReport(*sqlx.Tx, int) error
+
+	// ReviewLinkReports marks the open reports of the link as reviewed.
+	ReviewLinkReports(*sqlx.Tx, int) error
+
+	// IsAdmin returns true if the user is an admin.
+	IsAdmin(*sqlx.Tx, int) (bool, error)
+
+	// FindUserID returns the id of the user with the username.
+	FindUserID(*sqlx.Tx, string) (int, error)
+
+	// TransferLinks moves the links from the first user to the second, or all of them if the links are nil,
+	// except the deleted links and the links on branded domains.
+	// The links get the tags of the same names of the new owner and leave the folders of the previous one.
+	TransferLinks(*sqlx.Tx, int, int, []int) (int, error)
+
+	// CopyHits copies the hits of the first code to the second.
+	CopyHits(*sqlx.Tx, string, string) error
+
+	// DeleteHits deletes the hits of the code.
+	DeleteHits(*sqlx.Tx, string) error
+
+	// SoftDeleteLink moves the link with its clicks into the trash, sql.ErrNoRows is returned if it already is.
+	SoftDeleteLink(*sqlx.Tx, int, int64) error
+
+	// RestoreLink takes the link out of the trash, sql.ErrNoRows is returned if it is not in it.
+	RestoreLink(*sqlx.Tx, int) error
+
+	// FindTrash returns at most limit links of any user moved into the trash before the time, the oldest first.
+	FindTrash(*sqlx.Tx, time.Time, int) ([]Link, error)
+
+	// DeleteLink removes the link and its relation to the user.
+	DeleteLink(*sqlx.Tx, int) error
 }
@@ -0,0 +1,157 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"url/internal/errors"
+	"url/pkg/validators"
+)
+
+// uniqueViolation is the postgres error code of a duplicated unique key.
+const uniqueViolation = "23505"
+
+type TagDTO struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+type FolderDTO struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+type LinkTagsDTO struct {
+	Tags []string `json:"tags" validate:"max=20,dive,min=1,max=50"`
+}
+
+type LinkFolderDTO struct {
+	FolderID *int `json:"folder_id"`
+}
+
+func (s service) ListTags(ctx context.Context, userID int) ([]Tag, error) {
+	return s.store.FindUserTags(nil, userID)
+}
+
+func (s service) CreateTag(ctx context.Context, req TagDTO, userID int) (Tag, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Tag{}, err
+	}
+	tagID, err := s.store.CreateTag(nil, userID, req.Name)
+	if isUniqueViolation(err) {
+		return Tag{}, errors.Conflict(fmt.Sprintf("tag %s already exists", req.Name))
+	} else if err != nil {
+		return Tag{}, err
+	}
+	return Tag{ID: tagID, Name: req.Name}, nil
+}
+
+func (s service) RenameTag(ctx context.Context, tagID int, req TagDTO, userID int) (Tag, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Tag{}, err
+	}
+	err := s.store.RenameTag(nil, userID, tagID, req.Name)
+	if err == sql.ErrNoRows {
+		return Tag{}, errors.NotFound("")
+	} else if isUniqueViolation(err) {
+		return Tag{}, errors.Conflict(fmt.Sprintf("tag %s already exists", req.Name))
+	} else if err != nil {
+		return Tag{}, err
+	}
+	return s.store.FindUserTag(nil, userID, tagID)
+}
+
+func (s service) DeleteTag(ctx context.Context, tagID int, userID int) error {
+	err := s.store.DeleteTag(nil, userID, tagID)
+	if err == sql.ErrNoRows {
+		return errors.NotFound("")
+	}
+	return err
+}
+
+// SetLinkTags replaces the tags of the link, missing tags are created.
+func (s service) SetLinkTags(ctx context.Context, code string, req LinkTagsDTO, userID int) (Link, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Link{}, err
+	}
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	if err := s.store.ReplaceTags(nil, userID, link.ID, req.Tags); err != nil {
+		return Link{}, err
+	}
+	return s.Get(ctx, code, userID)
+}
+
+func (s service) ListFolders(ctx context.Context, userID int) ([]Folder, error) {
+	return s.store.FindUserFolders(nil, userID)
+}
+
+func (s service) CreateFolder(ctx context.Context, req FolderDTO, userID int) (Folder, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Folder{}, err
+	}
+	folderID, err := s.store.CreateFolder(nil, userID, req.Name)
+	if isUniqueViolation(err) {
+		return Folder{}, errors.Conflict(fmt.Sprintf("folder %s already exists", req.Name))
+	} else if err != nil {
+		return Folder{}, err
+	}
+	return Folder{ID: folderID, Name: req.Name}, nil
+}
+
+func (s service) RenameFolder(ctx context.Context, folderID int, req FolderDTO, userID int) (Folder, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return Folder{}, err
+	}
+	err := s.store.RenameFolder(nil, userID, folderID, req.Name)
+	if err == sql.ErrNoRows {
+		return Folder{}, errors.NotFound("")
+	} else if isUniqueViolation(err) {
+		return Folder{}, errors.Conflict(fmt.Sprintf("folder %s already exists", req.Name))
+	} else if err != nil {
+		return Folder{}, err
+	}
+	return s.store.FindUserFolder(nil, userID, folderID)
+}
+
+// DeleteFolder deletes the folder, its links are kept outside of any folder.
+func (s service) DeleteFolder(ctx context.Context, folderID int, userID int) error {
+	err := s.store.DeleteFolder(nil, userID, folderID)
+	if err == sql.ErrNoRows {
+		return errors.NotFound("")
+	}
+	return err
+}
+
+// SetLinkFolder moves the link into the folder, or out of its folder if no folder is given.
+func (s service) SetLinkFolder(ctx context.Context, code string, req LinkFolderDTO, userID int) (Link, error) {
+	if err := s.checkFolder(req.FolderID, userID); err != nil {
+		return Link{}, err
+	}
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	if err := s.store.MoveLink(nil, link.ID, req.FolderID); err != nil {
+		return Link{}, err
+	}
+	return s.Get(ctx, code, userID)
+}
+
+// checkFolder returns a bad request error if the folder is given but not owned by the user.
+func (s service) checkFolder(folderID *int, userID int) error {
+	if folderID == nil {
+		return nil
+	}
+	_, err := s.store.FindUserFolder(nil, userID, *folderID)
+	if err == sql.ErrNoRows {
+		return errors.BadRequest(fmt.Sprintf("folder %d does not exist", *folderID))
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	e, ok := err.(*pq.Error)
+	return ok && e.Code == uniqueViolation
+}
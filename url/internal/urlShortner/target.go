@@ -0,0 +1,135 @@
+package urlShortner
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"url/internal/track"
+)
+
+const (
+	// DeviceDesktop matches visitors using a desktop operating system.
+	DeviceDesktop = "desktop"
+
+	// DeviceMobile matches visitors using a mobile operating system.
+	DeviceMobile = "mobile"
+)
+
+// TargetRule redirects the visitors matching its operating system, device and countries to its url.
+// Empty conditions match every visitor.
+type TargetRule struct {
+	OS        string   `json:"os,omitempty" validate:"omitempty,oneof=Windows Mac Linux Android iOS 'Windows Mobile'"`
+	Device    string   `json:"device,omitempty" validate:"omitempty,oneof=desktop mobile"`
+	Countries []string `json:"countries,omitempty" validate:"omitempty,dive,len=2,uppercase"`
+	URL       string   `json:"url" validate:"required,url"`
+}
+
+// Match returns true if the visitor with the given user agent and country code matches the rule.
+func (rule TargetRule) Match(ua track.UserAgent, countryCode string) bool {
+	if rule.OS != "" && rule.OS != ua.OS {
+		return false
+	}
+	if len(rule.Countries) > 0 && !containsString(rule.Countries, countryCode) {
+		return false
+	}
+	switch rule.Device {
+	case DeviceDesktop:
+		return ua.IsDesktop()
+	case DeviceMobile:
+		return ua.IsMobile()
+	}
+	return true
+}
+
+// String describes the rule, it is stored on the hits redirected by the rule.
+func (rule TargetRule) String() string {
+	var conditions []string
+	if rule.OS != "" {
+		conditions = append(conditions, "os="+rule.OS)
+	}
+	if rule.Device != "" {
+		conditions = append(conditions, "device="+rule.Device)
+	}
+	if len(rule.Countries) > 0 {
+		conditions = append(conditions, "country="+strings.Join(rule.Countries, "|"))
+	}
+	if len(conditions) == 0 {
+		return "any"
+	}
+	return strings.Join(conditions, ",")
+}
+
+// TargetRules are the targeting rules of a link, evaluated in order.
+type TargetRules []TargetRule
+
+// Find returns the first rule matching the visitor with the given user agent and country code.
+func (rules TargetRules) Find(ua track.UserAgent, countryCode string) (TargetRule, bool) {
+	for _, rule := range rules {
+		if rule.Match(ua, countryCode) {
+			return rule, true
+		}
+	}
+	return TargetRule{}, false
+}
+
+// HasCountries returns true if any of the rules depends on the country of the visitor.
+func (rules TargetRules) HasCountries() bool {
+	for _, rule := range rules {
+		if len(rule.Countries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RedisArg implements the redis.Argument interface.
+func (rules TargetRules) RedisArg() interface{} {
+	return jsonArg(rules)
+}
+
+// RedisScan implements the redis.Scanner interface.
+func (rules *TargetRules) RedisScan(src interface{}) error {
+	return scanJSON(src, rules)
+}
+
+// Value implements the driver.Valuer interface.
+func (rules TargetRules) Value() (driver.Value, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(rules)
+}
+
+// Scan implements the sql.Scanner interface.
+func (rules *TargetRules) Scan(src interface{}) error {
+	return scanJSON(src, rules)
+}
+
+func containsString(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonArg encodes the value to be stored in redis.
+func jsonArg(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// scanJSON decodes a json value coming from redis or postgres.
+func scanJSON(src interface{}, v interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, v)
+	case string:
+		return json.Unmarshal([]byte(src), v)
+	}
+	return fmt.Errorf("cannot decode %T as json", src)
+}
@@ -0,0 +1,57 @@
+package urlShortner
+
+import (
+	"testing"
+	"url/internal/track"
+)
+
+func TestTargetRulesFind(t *testing.T) {
+	rules := TargetRules{
+		{OS: track.OSiOS, URL: "https://apps.apple.com/app"},
+		{OS: track.OSAndroid, Countries: []string{"DE", "AT"}, URL: "https://play.google.com/de"},
+		{Device: DeviceMobile, URL: "https://m.example.com"},
+		{Countries: []string{"FR"}, URL: "https://example.fr"},
+	}
+	tests := []struct {
+		name    string
+		os      string
+		country string
+		want    string
+	}{
+		{"os", track.OSiOS, "", "https://apps.apple.com/app"},
+		{"os and country", track.OSAndroid, "AT", "https://play.google.com/de"},
+		{"os without the country falls through to the device", track.OSAndroid, "US", "https://m.example.com"},
+		{"first match wins", track.OSiOS, "FR", "https://apps.apple.com/app"},
+		{"country", track.OSWindows, "FR", "https://example.fr"},
+		{"unknown country", track.OSLinux, "", ""},
+		{"no match", track.OSMac, "US", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := rules.Find(track.UserAgent{OS: tt.os}, tt.country)
+			if ok != (tt.want != "") {
+				t.Fatalf("got match %v, want a match %v", ok, tt.want != "")
+			}
+			if rule.URL != tt.want {
+				t.Errorf("got %s, want %s", rule.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetRulesFindEmpty(t *testing.T) {
+	if _, ok := (TargetRules{}).Find(track.UserAgent{OS: track.OSiOS}, "DE"); ok {
+		t.Error("got a match without rules")
+	}
+	// a rule without conditions matches every visitor
+	if rule, ok := (TargetRules{{URL: "https://example.com"}}).Find(track.UserAgent{}, ""); !ok || rule.String() != "any" {
+		t.Errorf("got %+v, %v, want the rule matching any visitor", rule, ok)
+	}
+}
+
+func TestTargetRuleString(t *testing.T) {
+	rule := TargetRule{OS: track.OSAndroid, Device: DeviceMobile, Countries: []string{"DE", "AT"}}
+	if got, want := rule.String(), "os=Android,device=mobile,country=DE|AT"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"url/internal/errors"
+	"url/pkg/validators"
+)
+
+type TransferDTO struct {
+	// To is the username of the new owner.
+	To string `json:"to" validate:"required"`
+}
+
+// AdminTransferDTO moves the links with the codes from a user to another, all of them without codes.
+type AdminTransferDTO struct {
+	From  string   `json:"from" validate:"required"`
+	To    string   `json:"to" validate:"required"`
+	Codes []string `json:"codes" validate:"omitempty,dive,required"`
+}
+
+type CloneDTO struct {
+	Alias     string `json:"alias"`
+	SimilarTo string `json:"similar_to"`
+	// KeepAnalytics copies the hits and the clicks of the link to the clone, which starts without them otherwise.
+	KeepAnalytics bool `json:"keep_analytics"`
+}
+
+// TransferResult is the number of links which got a new owner.
+type TransferResult struct {
+	Transferred int `json:"transferred"`
+}
+
+// Transfer gives the link of the user to another user.
+func (s service) Transfer(ctx context.Context, code string, req TransferDTO, userID int) (TransferResult, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return TransferResult{}, err
+	}
+	link, err := s.findTransferable(ctx, code, userID)
+	if err != nil {
+		return TransferResult{}, err
+	}
+	return s.transfer(userID, req.To, []int{link.ID})
+}
+
+// TransferAll gives all links of the user to another user, but the ones on branded domains of the user.
+func (s service) TransferAll(ctx context.Context, req TransferDTO, userID int) (TransferResult, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return TransferResult{}, err
+	}
+	return s.transfer(userID, req.To, nil)
+}
+
+// AdminTransfer moves the links of any user to another user, like the links of a user who left.
+func (s service) AdminTransfer(ctx context.Context, req AdminTransferDTO) (TransferResult, error) {
+	if ok, err := validators.Validate(req); !ok {
+		return TransferResult{}, err
+	}
+	fromUserID, err := s.findUserID(req.From)
+	if err != nil {
+		return TransferResult{}, err
+	}
+	var linkIDs []int
+	for _, code := range req.Codes {
+		link, err := s.findTransferable(ctx, code, fromUserID)
+		if err != nil {
+			return TransferResult{}, err
+		}
+		linkIDs = append(linkIDs, link.ID)
+	}
+	return s.transfer(fromUserID, req.To, linkIDs)
+}
+
+// findTransferable returns the link of the user if it can be transferred.
+// Links on branded domains stay with the owner of the domain.
+func (s service) findTransferable(ctx context.Context, code string, userID int) (Link, error) {
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	if link.Domain != "" {
+		return Link{}, errors.BadRequest(fmt.Sprintf("link %s is on the branded domain %s, it can not be transferred", code, link.Domain))
+	}
+	return link, nil
+}
+
+// transfer moves the links from the user to the user with the username in a single transaction.
+// All links of the user are moved if linkIDs is nil.
+func (s service) transfer(fromUserID int, to string, linkIDs []int) (TransferResult, error) {
+	toUserID, err := s.findUserID(to)
+	if err != nil {
+		return TransferResult{}, err
+	}
+	if toUserID == fromUserID {
+		return TransferResult{}, errors.BadRequest("the links already belong to the user")
+	}
+	tx := s.store.NewTx()
+	transferred, err := s.store.TransferLinks(tx, fromUserID, toUserID, linkIDs)
+	if err != nil {
+		s.store.Rollback(tx)
+		return TransferResult{}, err
+	}
+	s.store.Commit(tx)
+	return TransferResult{Transferred: transferred}, nil
+}
+
+// Clone creates a new link of the user with the settings of the link.
+func (s service) Clone(ctx context.Context, code string, req CloneDTO, userID int) (Link, error) {
+	if req.Alias != "" {
+		if req.SimilarTo != "" {
+			return Link{}, errors.BadRequest("alias and similar_to can not be used together")
+		}
+		if err := validateAlias(req.Alias); err != nil {
+			return Link{}, err
+		}
+	}
+	link, err := s.Get(ctx, code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	if link.State == LinkBanned {
+		return Link{}, ErrBanned
+	}
+	_, domain := splitCode(link.Code)
+	if domain != "" {
+		if _, err := s.checkDomain(domain, userID); err != nil {
+			return Link{}, err
+		}
+	}
+	// the policy may have changed since the link was created
+	dto := InputDTO{URL: link.URL, Targets: link.Targets, Variants: link.Variants}
+	if link.InactiveURL != nil {
+		dto.InactiveURL = *link.InactiveURL
+	}
+	if err := s.checkDestinations(ctx, dto); err != nil {
+		return Link{}, err
+	}
+	// the clone is built from the saved link, redis may have dropped the item of an expired link already
+	item := newItem(link, 0)
+	item.State = ""
+	if checkExpired(item) != nil {
+		return Link{}, errors.BadRequest("the link has expired, it can not be cloned")
+	}
+	if req.KeepAnalytics {
+		// the clicks are counted in redis only
+		stored, err := s.repo.FindOne(ctx, link.Code)
+		if err != nil && err != errItemNotFound {
+			return Link{}, err
+		}
+		item.Clicks = stored.Clicks
+	}
+
+	clone := link
+	if req.Alias != "" {
+		alias := scopeCode(req.Alias, domain)
+		claimed, err := s.repo.Claim(ctx, item, alias)
+		if err != nil {
+			return Link{}, err
+		}
+		if !claimed {
+			return Link{}, s.aliasConflict(ctx, req.Alias, domain)
+		}
+		clone.Code = alias
+	} else if clone.Code, err = s.repo.Create(ctx, item, req.SimilarTo, domain); err != nil {
+		return Link{}, err
+	}
+	if err := s.saveClone(link, clone, req.KeepAnalytics, userID); err != nil {
+		// the code is not saved, so it must not resolve either
+		if err := s.repo.Delete(ctx, clone.Code); err != nil {
+			s.logger.With(ctx).Errorf("failed deleting code %s of a failed clone: %s", clone.Code, err)
+		}
+		return Link{}, s.codeError(ctx, err, req.Alias, domain)
+	}
+	return s.Get(ctx, clone.Code, userID)
+}
+
+// saveClone saves the clone with the fetched metadata of the link, and its hits if they are kept.
+func (s service) saveClone(link, clone Link, keepAnalytics bool, userID int) error {
+	tx := s.store.NewTx()
+	if err := s.saveLink(tx, userID, clone); err != nil {
+		s.store.Rollback(tx)
+		return err
+	}
+	if link.Title != nil || !link.FetchedOG.IsEmpty() {
+		title := ""
+		if link.Title != nil {
+			title = *link.Title
+		}
+		if err := s.store.UpdateLinkMetadata(tx, clone.Code, title, link.FetchedOG); err != nil {
+			s.store.Rollback(tx)
+			return err
+		}
+	}
+	if keepAnalytics {
+		if err := s.store.CopyHits(tx, link.Code, clone.Code); err != nil {
+			s.store.Rollback(tx)
+			return err
+		}
+	}
+	s.store.Commit(tx)
+	return nil
+}
+
+func (s service) findUserID(username string) (int, error) {
+	userID, err := s.store.FindUserID(nil, username)
+	if err == sql.ErrNoRows {
+		return 0, errors.NotFound(fmt.Sprintf("user %s not found", username))
+	}
+	return userID, err
+}
@@ -0,0 +1,60 @@
+package urlShortner
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"url/internal/errors"
+	"url/pkg/policy"
+)
+
+func TestClone(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		link   Link
+		items  map[string]Item
+		clicks int64
+		status int
+	}{
+		{
+			name:   "stored link",
+			link:   Link{ID: 1, Code: "abc", URL: "https://example.com"},
+			items:  map[string]Item{"abc": {URL: "https://example.com", Clicks: 5}},
+			clicks: 5,
+		},
+		{
+			name: "link dropped by redis",
+			link: Link{ID: 1, Code: "abc", URL: "https://example.com"},
+		},
+		{
+			name:   "expired link",
+			link:   Link{ID: 1, Code: "abc", URL: "https://example.com", ExpiresAt: &expired},
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newStubRepository(tt.items)
+			s := service{store: newStubStore(tt.link), repo: repo, policy: policy.New(policy.Config{})}
+			_, err := s.Clone(context.Background(), "abc", CloneDTO{Alias: "copy", KeepAnalytics: true}, 1)
+			if tt.status != 0 {
+				if e, ok := err.(errors.ErrorResponse); !ok || e.Status != tt.status {
+					t.Fatalf("got error %v, want status %d", err, tt.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			item, ok := repo.items["copy"]
+			if !ok {
+				t.Fatal("the clone does not redirect")
+			}
+			if item.URL != tt.link.URL || item.Clicks != tt.clicks {
+				t.Errorf("got item %+v, want url %s with %d clicks", item, tt.link.URL, tt.clicks)
+			}
+		})
+	}
+}
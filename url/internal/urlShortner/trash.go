@@ -0,0 +1,187 @@
+package urlShortner
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"url/internal/errors"
+	"url/pkg/log"
+)
+
+// purgeBatchSize is the maximum number of links purged by the purger at once.
+const purgeBatchSize = 100
+
+// ListTrash returns the deleted links of the user which can still be restored, the last deleted first.
+func (s service) ListTrash(ctx context.Context, queries listQueries, userID int) (LinkPage, error) {
+	filter, page, perPage, err := s.listQueriesValidator(queries)
+	if err != nil {
+		return LinkPage{}, errors.BadRequest(err.Error())
+	}
+	filter.Deleted = true
+	links, total, err := s.store.FindUserLinks(nil, userID, filter)
+	if err != nil {
+		return LinkPage{}, err
+	}
+	for i := range links {
+		links[i].ShortURL = shortURL(links[i].Code)
+	}
+	return LinkPage{
+		Items:   links,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	}, nil
+}
+
+// Restore takes the link out of the trash, it redirects again.
+func (s service) Restore(ctx context.Context, code string, userID int) (Link, error) {
+	link, err := s.findTrashedLink(code, userID)
+	if err != nil {
+		return Link{}, err
+	}
+	var clicks int64
+	if link.DeletedClicks != nil {
+		clicks = *link.DeletedClicks
+	}
+	claimed, err := s.repo.Claim(ctx, newItem(link, clicks), link.Code)
+	if err != nil {
+		return Link{}, err
+	}
+	if !claimed {
+		return Link{}, errors.Conflict("the code of the link is used by another link")
+	}
+	if err := s.store.RestoreLink(nil, link.ID); err != nil {
+		if err := s.repo.Delete(ctx, link.Code); err != nil {
+			s.logger.With(ctx).Errorf("failed deleting code %s of a failed restore: %s", link.Code, err)
+		}
+		return Link{}, err
+	}
+	return s.Get(ctx, code, userID)
+}
+
+// Purge deletes the link in the trash with its hits, it can not be restored anymore.
+func (s service) Purge(ctx context.Context, code string, userID int) error {
+	link, err := s.findTrashedLink(code, userID)
+	if err != nil {
+		return err
+	}
+	return s.purge(link)
+}
+
+// PurgeTrash purges the links deleted before the given time and returns how many were purged.
+func (s service) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	for {
+		links, err := s.store.FindTrash(nil, before, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, link := range links {
+			if err := s.purge(link); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(links) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (s service) purge(link Link) error {
+	tx := s.store.NewTx()
+	if err := s.store.DeleteHits(tx, link.Code); err != nil {
+		s.store.Rollback(tx)
+		return err
+	}
+	if err := s.store.DeleteLink(tx, link.ID); err != nil {
+		s.store.Rollback(tx)
+		return err
+	}
+	s.store.Commit(tx)
+	return nil
+}
+
+func (s service) findTrashedLink(code string, userID int) (Link, error) {
+	link, err := s.store.FindUserTrashedLink(nil, userID, code)
+	if err == sql.ErrNoRows {
+		return Link{}, errors.NotFound("")
+	} else if err != nil {
+		return Link{}, err
+	}
+	return link, nil
+}
+
+// newItem builds the redirect data of the saved link.
+func newItem(link Link, clicks int64) Item {
+	item := Item{
+		URL:          link.URL,
+		Clicks:       clicks,
+		Targets:      link.Targets,
+		Variants:     link.Variants,
+		Interstitial: link.Interstitial,
+		OG:           link.OG.Or(link.FetchedOG),
+	}
+	if link.ExpiresAt != nil {
+		item.ExpiresAt = link.ExpiresAt.Unix()
+	}
+	if link.MaxClicks != nil {
+		item.MaxClicks = *link.MaxClicks
+	}
+	if link.Password != nil {
+		item.Password = *link.Password
+	}
+	if link.Status != nil {
+		item.Status = *link.Status
+	}
+	if link.ActiveFrom != nil {
+		item.ActiveFrom = link.ActiveFrom.Unix()
+	}
+	if link.ActiveUntil != nil {
+		item.ActiveUntil = link.ActiveUntil.Unix()
+	}
+	if link.InactiveURL != nil {
+		item.InactiveURL = *link.InactiveURL
+	}
+	if link.Title != nil {
+		item.Title = *link.Title
+	}
+	if link.Description != nil {
+		item.Description = *link.Description
+	}
+	if link.State != LinkActive {
+		item.State = link.State
+	}
+	return item
+}
+
+// Purger purges the links which are in the trash for longer than the retention period.
+type Purger struct {
+	service   Service
+	retention time.Duration
+	logger    log.Logger
+}
+
+// NewPurger creates a purger of the links deleted longer than the retention ago.
+func NewPurger(service Service, retention time.Duration, logger log.Logger) *Purger {
+	return &Purger{service, retention, logger}
+}
+
+// Run purges the trash every interval until the context is done.
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.service.PurgeTrash(ctx, time.Now().Add(-p.retention))
+		if err != nil {
+			p.logger.Errorf("failed purging the trash: %s", err)
+		} else if purged > 0 {
+			p.logger.Infof("purged %d links from the trash", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
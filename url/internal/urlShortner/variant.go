@@ -0,0 +1,63 @@
+package urlShortner
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"hash/fnv"
+)
+
+// Variant is a weighted destination of a link, used to split its traffic for experiments.
+type Variant struct {
+	Name   string `json:"name" validate:"required,max=50"`
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"required,min=1,max=1000"`
+}
+
+// Variants are the weighted destinations of a link.
+type Variants []Variant
+
+// Pick chooses a variant by its weight for the visitor with given key.
+// The same key always gets the same variant, so with a key which does not change, like track.VisitorKey,
+// a visitor sees a stable variant.
+func (variants Variants) Pick(key string) (Variant, bool) {
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return Variant{}, false
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	n := int(hash.Sum32() % uint32(total))
+	for _, variant := range variants {
+		if n < variant.Weight {
+			return variant, true
+		}
+		n -= variant.Weight
+	}
+	return Variant{}, false
+}
+
+// RedisArg implements the redis.Argument interface.
+func (variants Variants) RedisArg() interface{} {
+	return jsonArg(variants)
+}
+
+// RedisScan implements the redis.Scanner interface.
+func (variants *Variants) RedisScan(src interface{}) error {
+	return scanJSON(src, variants)
+}
+
+// Value implements the driver.Valuer interface.
+func (variants Variants) Value() (driver.Value, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(variants)
+}
+
+// Scan implements the sql.Scanner interface.
+func (variants *Variants) Scan(src interface{}) error {
+	return scanJSON(src, variants)
+}
@@ -0,0 +1,61 @@
+package urlShortner
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestVariantsPickDistribution(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants Variants
+	}{
+		{"even", Variants{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}},
+		{"uneven", Variants{{Name: "a", Weight: 1}, {Name: "b", Weight: 3}}},
+		{"three", Variants{{Name: "a", Weight: 50}, {Name: "b", Weight: 30}, {Name: "c", Weight: 20}}},
+	}
+	const visitors = 20000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := 0
+			for _, variant := range tt.variants {
+				total += variant.Weight
+			}
+			picks := make(map[string]int)
+			for i := 0; i < visitors; i++ {
+				variant, ok := tt.variants.Pick("visitor-" + strconv.Itoa(i))
+				if !ok {
+					t.Fatal("got no variant")
+				}
+				picks[variant.Name]++
+			}
+			for _, variant := range tt.variants {
+				want := float64(variant.Weight) / float64(total)
+				got := float64(picks[variant.Name]) / visitors
+				if math.Abs(got-want) > 0.02 {
+					t.Errorf("variant %s got %.3f of the visitors, want %.3f", variant.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVariantsPickIsStable(t *testing.T) {
+	variants := Variants{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}, {Name: "c", Weight: 1}}
+	for i := 0; i < 100; i++ {
+		key := "visitor-" + strconv.Itoa(i)
+		first, _ := variants.Pick(key)
+		for j := 0; j < 3; j++ {
+			if again, _ := variants.Pick(key); again.Name != first.Name {
+				t.Fatalf("visitor %s got variant %s, then %s", key, first.Name, again.Name)
+			}
+		}
+	}
+}
+
+func TestVariantsPickWithoutWeight(t *testing.T) {
+	if _, ok := (Variants{}).Pick("visitor"); ok {
+		t.Error("got a variant without variants")
+	}
+}
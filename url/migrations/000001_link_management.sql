@@ -0,0 +1,3 @@
+-- timestamps used to sort and filter the links of a user
+ALTER TABLE links ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT now();
+ALTER TABLE links ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT now();
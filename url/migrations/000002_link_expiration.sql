@@ -0,0 +1,3 @@
+-- links can expire at a date or after a number of clicks
+ALTER TABLE links ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS max_clicks BIGINT;
@@ -0,0 +1,2 @@
+-- bcrypt hash of the password protecting the link
+ALTER TABLE links ADD COLUMN IF NOT EXISTS password TEXT;
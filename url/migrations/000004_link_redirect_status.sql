@@ -0,0 +1,2 @@
+-- redirect status of the link, null uses the server default
+ALTER TABLE links ADD COLUMN IF NOT EXISTS redirect_status SMALLINT;
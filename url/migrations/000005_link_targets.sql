@@ -0,0 +1,5 @@
+-- device and platform targeting rules of the link
+ALTER TABLE links ADD COLUMN IF NOT EXISTS targets JSONB;
+
+-- targeting rule which chose the destination of the hit
+ALTER TABLE hit ADD COLUMN IF NOT EXISTS target VARCHAR(200);
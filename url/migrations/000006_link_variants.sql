@@ -0,0 +1,5 @@
+-- weighted destinations of the link
+ALTER TABLE links ADD COLUMN IF NOT EXISTS variants JSONB;
+
+-- variant chosen for the hit
+ALTER TABLE hit ADD COLUMN IF NOT EXISTS variant VARCHAR(50);
@@ -0,0 +1,2 @@
+-- every code, random or alias, belongs to a single link
+CREATE UNIQUE INDEX IF NOT EXISTS links_shortner_path_key ON links (shortner_path);
@@ -0,0 +1,2 @@
+-- existing links of a user are looked up by url to avoid duplicates
+CREATE INDEX IF NOT EXISTS links_url_idx ON links (url);
@@ -0,0 +1,7 @@
+-- canonical form of the destination, used to find duplicates and to display the link
+ALTER TABLE links ADD COLUMN IF NOT EXISTS canonical_url TEXT;
+UPDATE links SET canonical_url = url WHERE canonical_url IS NULL;
+ALTER TABLE links ALTER COLUMN canonical_url SET NOT NULL;
+
+DROP INDEX IF EXISTS links_url_idx;
+CREATE INDEX IF NOT EXISTS links_canonical_url_idx ON links (canonical_url);
@@ -0,0 +1,16 @@
+-- tags of the links, every user has their own tags
+CREATE TABLE IF NOT EXISTS tags (
+    tag_id  SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (user_id),
+    name    VARCHAR(50) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS link_tags (
+    link_id INT NOT NULL REFERENCES links (link_id),
+    tag_id  INT NOT NULL REFERENCES tags (tag_id),
+    PRIMARY KEY (link_id, tag_id)
+);
+
+-- the clicks of a link are counted by its path
+CREATE INDEX IF NOT EXISTS hit_path_idx ON hit (path);
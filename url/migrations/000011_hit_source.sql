@@ -0,0 +1,2 @@
+-- where the visitor comes from, like a QR code
+ALTER TABLE hit ADD COLUMN IF NOT EXISTS source VARCHAR(20);
@@ -0,0 +1,11 @@
+-- folders of the links, every user has their own folders
+CREATE TABLE IF NOT EXISTS folders (
+    folder_id SERIAL PRIMARY KEY,
+    user_id   INT NOT NULL REFERENCES users (user_id),
+    name      VARCHAR(50) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS folder_id INT REFERENCES folders (folder_id);
+CREATE INDEX IF NOT EXISTS links_folder_id_idx ON links (folder_id);
+CREATE INDEX IF NOT EXISTS link_tags_tag_id_idx ON link_tags (tag_id);
@@ -0,0 +1,11 @@
+-- previous destinations of the links, rows are only ever appended
+CREATE TABLE IF NOT EXISTS link_changes (
+    change_id    SERIAL PRIMARY KEY,
+    link_id      INT NOT NULL REFERENCES links (link_id),
+    user_id      INT NOT NULL REFERENCES users (user_id),
+    previous_url TEXT NOT NULL,
+    url          TEXT NOT NULL,
+    changed_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS link_changes_link_id_idx ON link_changes (link_id);
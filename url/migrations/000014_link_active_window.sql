@@ -0,0 +1,4 @@
+-- window in which the link redirects, and where visitors go outside of it
+ALTER TABLE links ADD COLUMN IF NOT EXISTS active_from TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS active_until TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS inactive_url TEXT;
@@ -0,0 +1,6 @@
+-- where the visitors of links which cannot be followed are sent, per user
+CREATE TABLE IF NOT EXISTS user_fallbacks (
+    user_id  INT PRIMARY KEY REFERENCES users (user_id),
+    url      TEXT NOT NULL DEFAULT '',
+    template TEXT NOT NULL DEFAULT ''
+);
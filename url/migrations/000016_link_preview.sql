@@ -0,0 +1,6 @@
+-- title of the destination page and description of the owner, shown on the preview page
+ALTER TABLE links ADD COLUMN IF NOT EXISTS title TEXT;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS description TEXT;
+
+-- every visitor of an interstitial link sees the preview page before being redirected
+ALTER TABLE links ADD COLUMN IF NOT EXISTS interstitial BOOLEAN NOT NULL DEFAULT FALSE;
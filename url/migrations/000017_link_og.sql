@@ -0,0 +1,3 @@
+-- metadata shown when the link is shared, set by the owner and fetched from the destination
+ALTER TABLE links ADD COLUMN IF NOT EXISTS og JSONB;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS og_fetched JSONB;
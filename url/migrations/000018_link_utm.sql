@@ -0,0 +1,8 @@
+-- campaign parameters added to the destination, used to filter the analytics
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_source VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_medium VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_term VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS utm_content VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS links_utm_campaign_idx ON links (utm_campaign);
@@ -0,0 +1,15 @@
+-- branded domains of the users, a domain serves links once its owner proved they control it
+CREATE TABLE IF NOT EXISTS domains (
+    domain_id   SERIAL PRIMARY KEY,
+    user_id     INT NOT NULL REFERENCES users (user_id),
+    name        VARCHAR(253) NOT NULL,
+    token       VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS domains_verified_name_key ON domains (name) WHERE verified_at IS NOT NULL;
+
+-- the codes of links on a branded domain are stored as code@domain, so they are unique per domain
+ALTER TABLE links ADD COLUMN IF NOT EXISTS domain VARCHAR(253) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS links_domain_idx ON links (domain);
@@ -0,0 +1,19 @@
+-- links are active, disabled by their owner or banned by an admin after abuse reports
+ALTER TABLE links ADD COLUMN IF NOT EXISTS state VARCHAR(10) NOT NULL DEFAULT 'active';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS ban_reason TEXT;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- abuse reports of visitors, a visitor reports a link once a day: once per fingerprint and reported_on
+CREATE TABLE IF NOT EXISTS link_reports (
+    report_id   SERIAL PRIMARY KEY,
+    link_id     INT NOT NULL REFERENCES links (link_id),
+    reason      VARCHAR(20) NOT NULL,
+    details     TEXT NOT NULL DEFAULT '',
+    fingerprint VARCHAR(32) NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+    reported_on DATE NOT NULL DEFAULT CURRENT_DATE,
+    reviewed_at TIMESTAMP,
+    UNIQUE (link_id, fingerprint, reported_on)
+);
+CREATE INDEX IF NOT EXISTS link_reports_open_idx ON link_reports (created_at) WHERE reviewed_at IS NULL;
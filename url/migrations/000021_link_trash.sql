@@ -0,0 +1,5 @@
+-- deleted links stay in the trash with their hits until they are restored or purged
+ALTER TABLE links ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+-- the click counter of a deleted link, which is restored with it
+ALTER TABLE links ADD COLUMN IF NOT EXISTS deleted_clicks BIGINT;
+CREATE INDEX IF NOT EXISTS links_deleted_at_idx ON links (deleted_at) WHERE deleted_at IS NOT NULL;
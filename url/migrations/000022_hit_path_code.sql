@@ -0,0 +1,4 @@
+-- hits used to be tracked by the path of the request, like /abc, which never matched links.shortner_path,
+-- so the analytics of the links were empty. Hits are tracked by the code of the link now, like abc or abc@domain
+-- on branded domains, and the hits tracked before are converted to it. Hits of other paths, like /, are kept.
+UPDATE hit SET path = substring(path FROM 2) WHERE path LIKE '/%' AND path <> '/';
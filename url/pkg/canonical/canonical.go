@@ -0,0 +1,89 @@
+package canonical
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// trackingParams are query parameters used to track visitors, which do not change the page.
+var trackingParams = []string{
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"yclid",
+	"_ga",
+}
+
+// URL returns the canonical form of the given url, so urls pointing to the same page are equal.
+// It lowercases the scheme and host, converts international domain names to punycode,
+// strips the default port, cleans the path and sorts the query.
+// Tracking parameters, like utm_source, are removed if stripTracking is set.
+func URL(rawURL string, stripTracking bool) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, port := strings.ToLower(strings.TrimSuffix(u.Hostname(), ".")), u.Port()
+	if net.ParseIP(host) == nil {
+		if host, err = idna.Lookup.ToASCII(host); err != nil {
+			return "", err
+		}
+	}
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 address without port
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	} else {
+		cleaned := path.Clean(u.Path)
+		if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		u.Path = cleaned
+	}
+	u.RawPath = ""
+
+	query := u.Query()
+	if stripTracking {
+		for param := range query {
+			if strings.HasPrefix(strings.ToLower(param), "utm_") || contains(trackingParams, strings.ToLower(param)) {
+				query.Del(param)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+func contains(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package canonical
+
+import "testing"
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		stripTracking bool
+		want          string
+	}{
+		{"lowercases scheme and host", "HTTPS://Example.COM/Path", false, "https://example.com/Path"},
+		{"adds the root path", "https://example.com", false, "https://example.com/"},
+		{"strips the default port", "http://example.com:80/a", false, "http://example.com/a"},
+		{"strips the default https port", "https://example.com:443/a", false, "https://example.com/a"},
+		{"keeps other ports", "https://example.com:8443/a", false, "https://example.com:8443/a"},
+		{"strips the trailing dot of the host", "https://example.com./a", false, "https://example.com/a"},
+		{"converts international domains", "https://bücher.example/", false, "https://xn--bcher-kva.example/"},
+		{"keeps ipv6 addresses", "http://[::1]:80/", false, "http://[::1]/"},
+		{"cleans the path", "https://example.com/a/./b/../c", false, "https://example.com/a/c"},
+		{"keeps the trailing slash", "https://example.com/a/b/", false, "https://example.com/a/b/"},
+		{"sorts the query", "https://example.com/?b=2&a=1", false, "https://example.com/?a=1&b=2"},
+		{"drops an empty query", "https://example.com/?", false, "https://example.com/"},
+		{"keeps tracking parameters", "https://example.com/?utm_source=x&a=1", false, "https://example.com/?a=1&utm_source=x"},
+		{"strips tracking parameters", "https://example.com/?UTM_Source=x&fbclid=y&gclid=z&a=1", true, "https://example.com/?a=1"},
+		{"keeps the fragment", "https://example.com/a#top", false, "https://example.com/a#top"},
+		{"trims spaces", "  https://example.com/a  ", false, "https://example.com/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := URL(tt.url, tt.stripTracking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLEqual(t *testing.T) {
+	a, _ := URL("HTTP://Example.com:80/a/../b?y=2&x=1", false)
+	b, _ := URL("http://example.com/b?x=1&y=2", false)
+	if a != b {
+		t.Errorf("got %s and %s for the same page", a, b)
+	}
+}
+
+func TestURLInvalid(t *testing.T) {
+	if _, err := URL("https://exa mple.com/%zz", false); err == nil {
+		t.Error("got no error for an invalid url")
+	}
+}
@@ -0,0 +1,322 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+
+	"golang.org/x/net/idna"
+)
+
+// The rules a destination can violate.
+const (
+	RuleScheme      = "scheme"
+	RulePrivateHost = "private_host"
+	RuleShortener   = "shortener"
+	RuleBlocklist   = "blocklist"
+)
+
+// DefaultSchemes are the schemes allowed when the config has none.
+var DefaultSchemes = []string{"http", "https"}
+
+// DefaultShorteners are well known url shorteners, links to them would hide the final destination.
+var DefaultShorteners = []string{
+	"bit.ly",
+	"bitly.com",
+	"buff.ly",
+	"cutt.ly",
+	"goo.gl",
+	"is.gd",
+	"lnkd.in",
+	"ow.ly",
+	"rb.gy",
+	"rebrand.ly",
+	"shorturl.at",
+	"t.co",
+	"t.ly",
+	"tiny.cc",
+	"tinyurl.com",
+	"v.gd",
+}
+
+// privateNetworks are the address ranges which are not reachable on the internet.
+var privateNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+// privateSuffixes are the host names used inside of private networks.
+var privateSuffixes = []string{"localhost", ".localhost", ".local", ".internal", ".lan", ".home.arpa"}
+
+// Resolver looks up the addresses of host names.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Config configures the destinations a Policy allows.
+type Config struct {
+	// Schemes are the allowed schemes, DefaultSchemes if empty.
+	Schemes []string
+	// AllowPrivate allows hosts of private networks, like localhost or 192.168.1.1.
+	AllowPrivate bool
+	// Shorteners are rules of url shorteners, in addition to DefaultShorteners.
+	Shorteners []string
+	// Blocklist are rules of hosts which are not allowed.
+	Blocklist []string
+	// Resolver resolves host names to check their addresses are not private, without it only addresses are checked.
+	Resolver Resolver
+}
+
+// Violation is the reason a destination is not allowed.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+// Error is required by the error interface.
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Policy decides which destinations links may have.
+type Policy struct {
+	schemes      map[string]bool
+	allowPrivate bool
+	shorteners   rules
+	blocklist    rules
+	resolver     Resolver
+}
+
+// New creates a policy of the config.
+func New(c Config) *Policy {
+	schemes := c.Schemes
+	if len(schemes) == 0 {
+		schemes = DefaultSchemes
+	}
+	p := &Policy{
+		schemes:      make(map[string]bool, len(schemes)),
+		allowPrivate: c.AllowPrivate,
+		shorteners:   newRules(append(append([]string{}, DefaultShorteners...), c.Shorteners...)),
+		blocklist:    newRules(c.Blocklist),
+		resolver:     c.Resolver,
+	}
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(scheme)] = true
+	}
+	return p
+}
+
+// Check returns a violation if the url is not allowed.
+func (p *Policy) Check(ctx context.Context, rawURL string) *Violation {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return &Violation{RuleScheme, err.Error()}
+	}
+	if scheme := strings.ToLower(u.Scheme); !p.schemes[scheme] {
+		return &Violation{RuleScheme, fmt.Sprintf("scheme %q is not allowed", scheme)}
+	}
+	host := Host(u)
+	if host == "" {
+		return &Violation{RuleScheme, "the url has no host"}
+	}
+	if p.blocklist.match(host) {
+		return &Violation{RuleBlocklist, fmt.Sprintf("host %s is blocked", host)}
+	}
+	if p.shorteners.match(host) {
+		return &Violation{RuleShortener, fmt.Sprintf("host %s is a url shortener", host)}
+	}
+	if !p.allowPrivate {
+		if private, err := p.isPrivate(ctx, host); err != nil {
+			return &Violation{RulePrivateHost, err.Error()}
+		} else if private {
+			return &Violation{RulePrivateHost, fmt.Sprintf("host %s is in a private network", host)}
+		}
+	}
+	return nil
+}
+
+// isPrivate returns true if the host is an address or a name of a private network.
+func (p *Policy) isPrivate(ctx context.Context, host string) (bool, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return isPrivateIP(ip), nil
+	}
+	// browsers read hosts like 2130706433 or 0x7f.1 as addresses
+	labels := strings.Split(host, ".")
+	if isNumeric(labels[len(labels)-1]) {
+		return true, nil
+	}
+	if len(labels) == 1 {
+		return true, nil
+	}
+	for _, suffix := range privateSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return true, nil
+		}
+	}
+	if p.resolver == nil {
+		return false, nil
+	}
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return false, fmt.Errorf("host %s can not be resolved", host)
+	}
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Host returns the lowercase ASCII host of the url without its port, as rules are written.
+func Host(u *url.URL) string {
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		return ascii
+	}
+	return host
+}
+
+// LoadRules reads the rules of a file, one per line.
+// Empty lines and lines starting with # are skipped.
+func LoadRules(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list = append(list, line)
+	}
+	return list, scanner.Err()
+}
+
+// rules match hosts.
+// A domain rule like example.com matches the domain and its subdomains,
+// a wildcard rule like *.example.com or paypal-*.com matches the hosts of its pattern.
+type rules struct {
+	domains   map[string]bool
+	wildcards []string
+}
+
+func newRules(list []string) rules {
+	r := rules{domains: make(map[string]bool, len(list))}
+	for _, rule := range list {
+		rule = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(rule), "."))
+		if rule == "" {
+			continue
+		}
+		rule = asciiRule(rule)
+		if strings.ContainsAny(rule, "*?[") {
+			r.wildcards = append(r.wildcards, rule)
+		} else {
+			r.domains[rule] = true
+		}
+	}
+	return r
+}
+
+// asciiRule converts the international labels of the rule like Host converts hosts, which are matched in ASCII.
+// Labels with wildcards are kept as they are.
+func asciiRule(rule string) string {
+	labels := strings.Split(rule, ".")
+	for i, label := range labels {
+		if strings.ContainsAny(label, "*?[") {
+			continue
+		}
+		if ascii, err := idna.Lookup.ToASCII(label); err == nil {
+			labels[i] = ascii
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+func (r rules) match(host string) bool {
+	for domain := host; domain != ""; {
+		if r.domains[domain] {
+			return true
+		}
+		i := strings.Index(domain, ".")
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	for _, pattern := range r.wildcards {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Control refuses connections to addresses of private networks, whatever name they were resolved from.
+// It is meant for net.Dialer.Control of clients requesting urls given by users.
+func Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+		return fmt.Errorf("address %s is in a private network", host)
+	}
+	return nil
+}
+
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNumeric returns true if the label is a decimal or hexadecimal number, which no top level domain is.
+func isNumeric(label string) bool {
+	digits := "0123456789"
+	if strings.HasPrefix(label, "0x") {
+		label, digits = label[2:], "0123456789abcdef"
+	}
+	return strings.Trim(label, digits) == ""
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
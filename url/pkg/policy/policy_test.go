@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// stubResolver resolves every host to the same addresses.
+type stubResolver struct {
+	addrs []string
+	err   error
+}
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, addr := range r.addrs {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(addr)})
+	}
+	return addrs, r.err
+}
+
+func TestCheck(t *testing.T) {
+	p := New(Config{
+		Shorteners: []string{"sho.rt"},
+		Blocklist:  []string{"evil.example", "*.phish.example", "paypal-*.com", "bücher.example", "*.straße.example"},
+	})
+	tests := []struct {
+		name string
+		url  string
+		rule string
+	}{
+		{"public host", "https://example.com/a", ""},
+		{"public address", "http://93.184.216.34/", ""},
+		{"scheme", "ftp://example.com/", RuleScheme},
+		{"javascript", "javascript:alert(1)", RuleScheme},
+		{"no host", "https:///a", RuleScheme},
+		{"uppercase scheme", "HTTPS://example.com/", ""},
+
+		{"loopback", "http://127.0.0.1/", RulePrivateHost},
+		{"loopback ipv6", "http://[::1]:8080/", RulePrivateHost},
+		{"metadata service", "http://169.254.169.254/latest/meta-data/", RulePrivateHost},
+		{"private network", "http://192.168.1.1/", RulePrivateHost},
+		{"localhost", "http://localhost:3000/", RulePrivateHost},
+		{"localhost subdomain", "http://api.localhost/", RulePrivateHost},
+		{"local suffix", "http://printer.local/", RulePrivateHost},
+		{"single label", "http://intranet/", RulePrivateHost},
+		{"decimal address", "http://2130706433/", RulePrivateHost},
+		{"hexadecimal address", "http://0x7f.1/", RulePrivateHost},
+		{"numeric last label", "http://127.1/", RulePrivateHost},
+		{"letters in the last label", "http://example.cafe/", ""},
+		{"hexadecimal looking top level domain", "http://example.dad/", ""},
+
+		{"default shortener", "https://bit.ly/abc", RuleShortener},
+		{"subdomain of a shortener", "https://www.tinyurl.com/abc", RuleShortener},
+		{"configured shortener", "https://sho.rt/abc", RuleShortener},
+
+		{"blocked domain", "https://evil.example/", RuleBlocklist},
+		{"blocked subdomain", "https://login.evil.example/", RuleBlocklist},
+		{"uppercase blocked domain", "https://EVIL.example./", RuleBlocklist},
+		{"similar domain", "https://notevil.example/", ""},
+		{"wildcard subdomain", "https://a.phish.example/", RuleBlocklist},
+		{"wildcard without subdomain", "https://phish.example/", ""},
+		{"wildcard in the name", "https://paypal-login.com/", RuleBlocklist},
+		{"international domain", "https://bücher.example/", RuleBlocklist},
+		{"international domain as punycode", "https://xn--bcher-kva.example/", RuleBlocklist},
+		{"international wildcard", "https://shop.straße.example/", RuleBlocklist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := p.Check(context.Background(), tt.url)
+			rule := ""
+			if v != nil {
+				rule = v.Rule
+			}
+			if rule != tt.rule {
+				t.Errorf("got rule %q (%v), want %q", rule, v, tt.rule)
+			}
+		})
+	}
+}
+
+func TestCheckAllowPrivate(t *testing.T) {
+	p := New(Config{AllowPrivate: true})
+	if v := p.Check(context.Background(), "http://localhost:3000/"); v != nil {
+		t.Errorf("got %v for a private host which is allowed", v)
+	}
+}
+
+func TestCheckSchemes(t *testing.T) {
+	p := New(Config{Schemes: []string{"HTTPS"}})
+	if v := p.Check(context.Background(), "https://example.com/"); v != nil {
+		t.Errorf("got %v for an allowed scheme", v)
+	}
+	if v := p.Check(context.Background(), "http://example.com/"); v == nil || v.Rule != RuleScheme {
+		t.Errorf("got %v for a scheme which is not allowed", v)
+	}
+}
+
+func TestCheckResolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver stubResolver
+		rule     string
+	}{
+		{"public", stubResolver{addrs: []string{"93.184.216.34"}}, ""},
+		{"private", stubResolver{addrs: []string{"93.184.216.34", "10.0.0.1"}}, RulePrivateHost},
+		{"unresolvable", stubResolver{err: errors.New("no such host")}, RulePrivateHost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New(Config{Resolver: tt.resolver}).Check(context.Background(), "https://example.com/")
+			rule := ""
+			if v != nil {
+				rule = v.Rule
+			}
+			if rule != tt.rule {
+				t.Errorf("got rule %q (%v), want %q", rule, v, tt.rule)
+			}
+		})
+	}
+}
+
+func TestControl(t *testing.T) {
+	tests := []struct {
+		address string
+		err     bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"169.254.169.254:80", true},
+		{"10.1.2.3:8080", true},
+		{"[fd00::1]:80", true},
+		{"0.0.0.0:80", true},
+	}
+	for _, tt := range tests {
+		if err := Control("tcp", tt.address, nil); (err != nil) != tt.err {
+			t.Errorf("address %s: got error %v, want error %v", tt.address, err, tt.err)
+		}
+	}
+}
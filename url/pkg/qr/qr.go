@@ -0,0 +1,131 @@
+// Package qr renders QR codes as PNG and SVG images.
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Levels maps the error correction level names to the levels of the encoder.
+// L recovers 7% of the code, M 15%, Q 25% and H 30%.
+var Levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options describes how a QR code is drawn.
+type Options struct {
+	// Size is the width and height of the image in pixels.
+	Size int
+	// Margin is the width of the quiet zone around the code in modules.
+	Margin int
+	// Level is the error correction level, one of L, M, Q and H.
+	Level      string
+	Foreground color.Color
+	Background color.Color
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	bitmap  [][]bool
+	options Options
+}
+
+// New encodes the content with the given options.
+func New(content string, options Options) (Code, error) {
+	level, ok := Levels[options.Level]
+	if !ok {
+		return Code{}, fmt.Errorf("unknown error correction level %s", options.Level)
+	}
+	q, err := qrcode.New(content, level)
+	if err != nil {
+		return Code{}, err
+	}
+	// the margin is drawn by the code itself, so it can be chosen freely
+	q.DisableBorder = true
+	return Code{bitmap: q.Bitmap(), options: options}, nil
+}
+
+// Modules returns the width of the code in modules, including the margin.
+func (c Code) Modules() int {
+	return len(c.bitmap) + 2*c.options.Margin
+}
+
+// dark returns true if the module at the given position is dark, positions in the margin are light.
+func (c Code) dark(x, y int) bool {
+	x, y = x-c.options.Margin, y-c.options.Margin
+	if x < 0 || y < 0 || y >= len(c.bitmap) || x >= len(c.bitmap[y]) {
+		return false
+	}
+	return c.bitmap[y][x]
+}
+
+// PNG draws the code as a PNG image.
+func (c Code) PNG() ([]byte, error) {
+	modules := c.Modules()
+	img := image.NewPaletted(image.Rect(0, 0, c.options.Size, c.options.Size),
+		color.Palette{c.options.Background, c.options.Foreground})
+	for y := 0; y < c.options.Size; y++ {
+		for x := 0; x < c.options.Size; x++ {
+			if c.dark(x*modules/c.options.Size, y*modules/c.options.Size) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG draws the code as an SVG image, every module is one unit of the view box.
+func (c Code) SVG() []byte {
+	modules := c.Modules()
+	var path strings.Builder
+	for y := 0; y < modules; y++ {
+		for x := 0; x < modules; x++ {
+			if c.dark(x, y) {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		c.options.Size, c.options.Size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, modules, modules, Hex(c.options.Background))
+	fmt.Fprintf(&buf, `<path d="%s" fill="%s"/>`, path.String(), Hex(c.options.Foreground))
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// ParseHex parses a color written as rrggbb or as its short form rgb, with or without a leading #.
+func ParseHex(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("%s is not a rrggbb color", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a rrggbb color", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Hex writes the color as #rrggbb.
+func Hex(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
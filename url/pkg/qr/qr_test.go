@@ -0,0 +1,129 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		s    string
+		want color.Color
+		err  bool
+	}{
+		{"ff8000", color.RGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}, false},
+		{"#ff8000", color.RGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}, false},
+		{"#FFFFFF", color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, false},
+		{"f80", color.RGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}, false},
+		{"#000", color.RGBA{A: 0xff}, false},
+		{"", nil, true},
+		{"#", nil, true},
+		{"ff80", nil, true},
+		{"ff80000", nil, true},
+		{"gg8000", nil, true},
+		{"12345g", nil, true},
+		{"1 2345", nil, true},
+		{"+12345", nil, true},
+		{"##ff8000", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHex(tt.s)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: got error %v, want error %v", tt.s, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	if got := Hex(color.RGBA{R: 0x12, G: 0xab, B: 0x00, A: 0xff}); got != "#12ab00" {
+		t.Errorf("got %s, want #12ab00", got)
+	}
+}
+
+func TestNewUnknownLevel(t *testing.T) {
+	if _, err := New("https://sho.rt/abc", Options{Level: "X"}); err == nil {
+		t.Error("got no error for an unknown level")
+	}
+}
+
+func newTestCode(t *testing.T, size, margin int) Code {
+	code, err := New("https://sho.rt/abc?src=qr", Options{Size: size, Margin: margin, Level: "M", Foreground: color.Black, Background: color.White})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestPNG(t *testing.T) {
+	tests := []struct {
+		size   int
+		margin int
+	}{
+		{64, 0},
+		{256, 4},
+		{333, 16},
+		{2048, 4},
+	}
+	for _, tt := range tests {
+		code := newTestCode(t, tt.size, tt.margin)
+		data, err := code.PNG()
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("size %d: %s", tt.size, err)
+		}
+		if bounds := img.Bounds(); bounds.Dx() != tt.size || bounds.Dy() != tt.size {
+			t.Errorf("size %d: got an image of %dx%d", tt.size, bounds.Dx(), bounds.Dy())
+		}
+		// the margin is light, the top left finder pattern right after it is dark
+		if r, _, _, _ := img.At(0, 0).RGBA(); tt.margin > 0 && r != 0xffff {
+			t.Errorf("size %d: got a dark margin", tt.size)
+		}
+		center := (2*tt.margin + 1) * tt.size / (2 * code.Modules())
+		if r, _, _, _ := img.At(center, center).RGBA(); r != 0 {
+			t.Errorf("size %d: got a light finder pattern", tt.size)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	code := newTestCode(t, 256, 4)
+	svg := code.SVG()
+	decoder := xml.NewDecoder(bytes.NewReader(svg))
+	var elements []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("got malformed svg: %s", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			elements = append(elements, start.Name.Local)
+			if start.Name.Local == "svg" {
+				for _, attr := range start.Attr {
+					if attr.Name.Local == "width" && attr.Value != "256" {
+						t.Errorf("got width %s, want 256", attr.Value)
+					}
+				}
+			}
+		}
+	}
+	if got := strings.Join(elements, ","); got != "svg,rect,path" {
+		t.Errorf("got elements %s, want svg,rect,path", got)
+	}
+	if !bytes.Contains(svg, []byte(`fill="#000000"`)) || !bytes.Contains(svg, []byte(`fill="#ffffff"`)) {
+		t.Errorf("got svg without its colors: %s", svg)
+	}
+}